@@ -2,20 +2,34 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 
 	"github.com/SavelyDev/crud-app/internal/config"
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/internal/metrics"
+	"github.com/SavelyDev/crud-app/internal/repository/memory"
 	"github.com/SavelyDev/crud-app/internal/repository/psql"
 	"github.com/SavelyDev/crud-app/internal/service"
+	"github.com/SavelyDev/crud-app/internal/transport/graphql"
+	"github.com/SavelyDev/crud-app/internal/transport/grpc"
 	"github.com/SavelyDev/crud-app/internal/transport/rest"
+	"github.com/SavelyDev/crud-app/migrations"
 	"github.com/SavelyDev/crud-app/pkg/database"
 	"github.com/SavelyDev/crud-app/pkg/hash"
 	"github.com/SavelyDev/crud-app/pkg/server"
+	"github.com/SavelyDev/crud-app/pkg/storage"
+	"github.com/SavelyDev/crud-app/pkg/tracing"
 )
 
 // @title CRUD-APP API
@@ -34,7 +48,7 @@ const (
 )
 
 func init() {
-	logrus.SetFormatter(&logrus.TextFormatter{})
+	logrus.SetFormatter(&logrus.JSONFormatter{})
 	logrus.SetOutput(os.Stdout)
 	logrus.SetLevel(logrus.InfoLevel)
 }
@@ -45,14 +59,28 @@ func main() {
 		logrus.Fatal(err)
 	}
 
-	db, err := database.New(database.Config{
+	shutdownTracing, err := tracing.New(context.Background(), tracing.Config{
+		ServiceName: cfg.Tracing.ServiceName,
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		File:        cfg.Tracing.File,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	dbConfig := database.Config{
 		Host:     cfg.DB.Host,
 		Port:     cfg.DB.Port,
 		Username: cfg.DB.Username,
 		Name:     cfg.DB.Name,
 		SSLMode:  cfg.DB.SSLMode,
 		Password: cfg.DB.Password,
-	})
+	}
+
+	db, err := database.New(dbConfig)
 	if err != nil {
 		logrus.Fatal(err)
 	}
@@ -64,16 +92,115 @@ func main() {
 	tokensRepo := psql.NewTokensRepo(db)
 	todoListRepo := psql.NewTodoListRepo(db)
 	todoItemRepo := psql.NewTodoItemRepo(db)
+	trashRepo := psql.NewTrashRepo(db)
+	searchRepo := psql.NewSearchRepo(db, cfg.Search.Language)
+	commentRepo := psql.NewCommentRepo(db)
+	notificationRepo := psql.NewNotificationRepo(db)
+	attachmentRepo := psql.NewAttachmentRepo(db)
+	auditRepo := psql.NewAuditRepo(db)
+	idempotencyRepo := psql.NewIdempotencyRepo(db)
+	eventRepo := psql.NewEventRepo(db, database.ConnString(dbConfig))
+	webhookRepo := psql.NewWebhookRepo(db)
+	syncRepo := psql.NewSyncRepo(db)
+	statsRepo := psql.NewStatsRepo(db)
+
+	if err := searchRepo.SyncLanguage(context.Background()); err != nil {
+		logrus.Fatal(err)
+	}
+
+	blobStore, err := newBlobStore(cfg.Storage)
+	if err != nil {
+		logrus.Fatal(err)
+	}
 
 	authService := service.NewAuthService(authRepo, tokensRepo, hasher, cfg.Auth.TokenTTL, cfg.Auth.Secret)
-	todoListService := service.NewTodoListService(todoListRepo)
+	todoListService := service.NewTodoListService(todoListRepo, todoItemRepo)
 	todoItemService := service.NewTodoItemService(todoItemRepo, todoListRepo)
+	trashService := service.NewTrashService(trashRepo, cfg.Trash.Retention)
+	searchService := service.NewSearchService(searchRepo)
+	commentService := service.NewCommentService(commentRepo)
+	notificationService := service.NewNotificationService(notificationRepo)
+	attachmentService := service.NewAttachmentService(attachmentRepo, todoItemRepo, blobStore,
+		service.AttachmentConfig{
+			MaxSize:      cfg.Attachments.MaxSize,
+			AllowedTypes: cfg.Attachments.AllowedTypes,
+			URLTTL:       cfg.Attachments.URLTTL,
+			SigningKey:   cfg.Attachments.SigningKey,
+		})
+
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	auditService := service.NewAuditService(auditRepo, cfg.Audit.UndoWindow)
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo, cfg.Idempotency.TTL, cfg.Idempotency.Lease)
+	eventService := service.NewEventService(eventRepo, cfg.Events.BufferSize)
+	webhookService := service.NewWebhookService(webhookRepo, todoListRepo, eventRepo, service.WebhookConfig{
+		Timeout:      cfg.Webhooks.Timeout,
+		BatchSize:    cfg.Webhooks.BatchSize,
+		MaxAttempts:  cfg.Webhooks.MaxAttempts,
+		BackoffBase:  cfg.Webhooks.BackoffBase,
+		BackoffMax:   cfg.Webhooks.BackoffMax,
+		DisableAfter: cfg.Webhooks.DisableAfter,
+	})
+	rateLimitService := service.NewRateLimitService(newRateLimitStore(cfg.RateLimit, db),
+		rateLimits(cfg.RateLimit))
+	syncService := service.NewSyncService(syncRepo, todoListRepo, todoItemRepo, cfg.Trash.Retention)
+
+	workers := service.NewWorkers()
+	workers.Go("trash_retention", func() { trashService.RunRetention(workersCtx, cfg.Trash.PurgeInterval) })
+	workers.Go("attachment_cleanup", func() { attachmentService.RunCleanup(workersCtx, cfg.Attachments.CleanupInterval) })
+	workers.Go("idempotency_cleanup", func() { idempotencyService.RunCleanup(workersCtx, cfg.Idempotency.CleanupInterval) })
+	workers.Go("events", func() { eventService.Run(workersCtx) })
+	workers.Go("webhook_deliveries", func() { webhookService.RunDeliveries(workersCtx, cfg.Webhooks.DeliveryInterval) })
+	workers.Go("rate_limit_cleanup", func() { rateLimitService.RunCleanup(workersCtx, cfg.RateLimit.CleanupInterval) })
 
-	hand := rest.NewHandler(authService, todoListService, todoItemService)
+	migration, err := database.LatestMigration(migrations.FS)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	healthService := service.NewHealthService(database.NewChecker(db, migration), workers)
+
+	versioning, err := newVersioning(cfg.API)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	graphqlHandler := graphql.NewHandler(authService, todoListService, todoItemService, eventService)
+
+	hand := rest.NewHandler(authService, todoListService, todoItemService, trashService, searchService,
+		commentService, notificationService, attachmentService, auditService, idempotencyService,
+		eventService, webhookService, syncService, graphqlHandler, rateLimitService, versioning,
+		rest.CORS(cfg.CORS), rest.SecurityHeaders(cfg.Security), cfg.Server.TrustedProxies, cfg.DB.Timeout,
+		healthService)
 
 	srv := server.NewServer(cfg.Server.Port, hand.InitRouter())
 	go func() {
-		if err := srv.Run(); err != nil {
+		if err := srv.Run(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logrus.Fatal(err)
+		}
+	}()
+
+	grpcHandler := grpc.NewHandler(authService, todoListService, todoItemService, cfg.DB.Timeout)
+
+	grpcSrv := server.NewGRPCServer(cfg.GRPC.Port, grpcHandler.InitServer())
+	go func() {
+		if err := grpcSrv.Run(); err != nil {
+			logrus.Fatal(err)
+		}
+	}()
+
+	prometheus.MustRegister(
+		collectors.NewDBStatsCollector(db, cfg.DB.Name),
+		metrics.NewBusinessCollector(statsRepo, cfg.Metrics.ActivityWindow),
+	)
+
+	metricsMux := http.NewServeMux()
+	metricsMux.Handle("/metrics", promhttp.Handler())
+
+	metricsSrv := server.NewServer(cfg.Metrics.Port, metricsMux)
+	go func() {
+		if err := metricsSrv.Run(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			logrus.Fatal(err)
 		}
 	}()
@@ -82,10 +209,90 @@ func main() {
 	signal.Notify(quit, syscall.SIGTERM, syscall.SIGINT)
 	<-quit
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// Fail readiness first and keep serving for a while, so that load
+	// balancers stop routing to this instance before it stops accepting
+	// connections.
+	healthService.Drain()
+	grpcHandler.Drain()
+	time.Sleep(cfg.Server.DrainDelay)
+
+	stopWorkers()
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		logrus.Fatal(err)
+		logrus.Error(err)
 	}
+
+	if err := grpcSrv.Shutdown(ctx); err != nil {
+		logrus.Error(err)
+	}
+
+	if err := workers.Wait(ctx); err != nil {
+		logrus.WithField("stopped", workers.Stopped()).Error(err)
+	}
+
+	if err := metricsSrv.Shutdown(ctx); err != nil {
+		logrus.Error(err)
+	}
+
+	if err := shutdownTracing(ctx); err != nil {
+		logrus.Error(err)
+	}
+}
+
+func newVersioning(cfg config.API) (rest.Versioning, error) {
+	var versioning rest.Versioning
+	var err error
+
+	if cfg.V1DeprecatedAt != "" {
+		if versioning.V1DeprecatedAt, err = time.Parse(time.DateOnly, cfg.V1DeprecatedAt); err != nil {
+			return versioning, err
+		}
+	}
+
+	if cfg.V1SunsetAt != "" {
+		if versioning.V1SunsetAt, err = time.Parse(time.DateOnly, cfg.V1SunsetAt); err != nil {
+			return versioning, err
+		}
+	}
+
+	return versioning, nil
+}
+
+func newRateLimitStore(cfg config.RateLimit, db *sql.DB) service.RateLimitStore {
+	if cfg.Store == "postgres" {
+		return psql.NewRateLimitRepo(db)
+	}
+
+	return memory.NewRateLimitStore()
+}
+
+func rateLimits(cfg config.RateLimit) map[string]domain.RateLimit {
+	limits := make(map[string]domain.RateLimit, len(cfg.Groups))
+	for group, limit := range cfg.Groups {
+		limits[group] = domain.RateLimit{
+			Limit:  limit.Limit,
+			Period: limit.Period,
+			Burst:  limit.Burst,
+		}
+	}
+
+	return limits
+}
+
+func newBlobStore(cfg config.Storage) (storage.BlobStore, error) {
+	if cfg.Driver == "s3" {
+		return storage.NewS3Store(context.Background(), storage.S3Config{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+			UseSSL:    cfg.S3.UseSSL,
+		})
+	}
+
+	return storage.NewLocalStore(cfg.Local.Dir)
 }
@@ -1,6 +1,9 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"net"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -15,10 +18,80 @@ type Postgres struct {
 	Name     string
 	SSLMode  string
 	Password string
+	// Timeout bounds the queries of a request.
+	Timeout time.Duration
 }
 
 type Server struct {
 	Port int
+	// DrainDelay is how long the server keeps serving after readiness
+	// starts failing, for load balancers to take the instance out.
+	DrainDelay time.Duration `mapstructure:"drain_delay"`
+	// ShutdownTimeout bounds the wait for in-flight requests and
+	// background jobs.
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+	// TrustedProxies are the addresses or CIDRs of the proxies in front of
+	// the server, the client IP is only read from X-Forwarded-For when the
+	// request comes from one of them.
+	TrustedProxies []string `mapstructure:"trusted_proxies" split_words:"true"`
+}
+
+// API holds the v1 deprecation and sunset dates, as YYYY-MM-DD.
+type API struct {
+	V1DeprecatedAt string `mapstructure:"v1_deprecated_at"`
+	V1SunsetAt     string `mapstructure:"v1_sunset_at"`
+}
+
+type RateLimitGroup struct {
+	Limit  int
+	Period time.Duration
+	Burst  int
+}
+
+type RateLimit struct {
+	// Store is "memory", or "postgres" to share limits between instances.
+	Store           string
+	CleanupInterval time.Duration `mapstructure:"cleanup_interval"`
+	Groups          map[string]RateLimitGroup
+}
+
+type CORS struct {
+	AllowedOrigins   []string      `mapstructure:"allowed_origins" split_words:"true"`
+	AllowedMethods   []string      `mapstructure:"allowed_methods" split_words:"true"`
+	AllowedHeaders   []string      `mapstructure:"allowed_headers" split_words:"true"`
+	ExposedHeaders   []string      `mapstructure:"exposed_headers" split_words:"true"`
+	AllowCredentials bool          `mapstructure:"allow_credentials" split_words:"true"`
+	MaxAge           time.Duration `mapstructure:"max_age" split_words:"true"`
+}
+
+type Security struct {
+	Enabled                      bool
+	HSTSMaxAge                   time.Duration `mapstructure:"hsts_max_age" envconfig:"hsts_max_age"`
+	FrameOptions                 string        `mapstructure:"frame_options" split_words:"true"`
+	ContentSecurityPolicy        string        `mapstructure:"content_security_policy" split_words:"true"`
+	SwaggerContentSecurityPolicy string        `mapstructure:"swagger_content_security_policy" split_words:"true"`
+}
+
+// Metrics is served on its own port, which isn't meant to be exposed.
+type Metrics struct {
+	Port int
+	// ActivityWindow is the period of the active users and created items
+	// gauges.
+	ActivityWindow time.Duration `mapstructure:"activity_window"`
+}
+
+type Tracing struct {
+	ServiceName string `mapstructure:"service_name" split_words:"true"`
+	// Exporter is "otlp", "stdout" or "file", empty disables tracing.
+	Exporter    string
+	Endpoint    string
+	Insecure    bool
+	File        string
+	SampleRatio float64 `mapstructure:"sample_ratio" split_words:"true"`
+}
+
+type GRPC struct {
+	Port int
 }
 
 type Auth struct {
@@ -30,11 +103,91 @@ type Hash struct {
 	Slat string
 }
 
+type Trash struct {
+	Retention     time.Duration `mapstructure:"retention"`
+	PurgeInterval time.Duration `mapstructure:"purge_interval"`
+}
+
+type Search struct {
+	Language string
+}
+
+type Attachments struct {
+	MaxSize         int64         `mapstructure:"max_size"`
+	AllowedTypes    []string      `mapstructure:"allowed_types"`
+	URLTTL          time.Duration `mapstructure:"url_ttl"`
+	CleanupInterval time.Duration `mapstructure:"cleanup_interval"`
+	// SigningKey signs the download URLs, it's kept apart from the auth
+	// secret so that either can be rotated alone.
+	SigningKey []byte `mapstructure:"signing_key" split_words:"true"`
+}
+
+type LocalStorage struct {
+	Dir string
+}
+
+type S3Storage struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	UseSSL    bool   `mapstructure:"use_ssl" split_words:"true"`
+	AccessKey string `split_words:"true"`
+	SecretKey string `split_words:"true"`
+}
+
+type Storage struct {
+	Driver string
+	Local  LocalStorage
+	S3     S3Storage
+}
+
+type Audit struct {
+	UndoWindow time.Duration `mapstructure:"undo_window"`
+}
+
+type Idempotency struct {
+	TTL time.Duration `mapstructure:"ttl"`
+	// Lease is how long a key stays claimed by a request still in progress,
+	// so that the key frees itself when the process dies mid-request. It
+	// must outlast db.timeout.
+	Lease           time.Duration `mapstructure:"lease"`
+	CleanupInterval time.Duration `mapstructure:"cleanup_interval"`
+}
+
+type Events struct {
+	BufferSize int `mapstructure:"buffer_size"`
+}
+
+type Webhooks struct {
+	DeliveryInterval time.Duration `mapstructure:"delivery_interval"`
+	Timeout          time.Duration `mapstructure:"timeout"`
+	BatchSize        int           `mapstructure:"batch_size"`
+	MaxAttempts      int           `mapstructure:"max_attempts"`
+	BackoffBase      time.Duration `mapstructure:"backoff_base"`
+	BackoffMax       time.Duration `mapstructure:"backoff_max"`
+	DisableAfter     int           `mapstructure:"disable_after"`
+}
+
 type Config struct {
-	DB     Postgres
-	Server Server
-	Auth   Auth
-	Hash   Hash
+	DB          Postgres
+	Server      Server
+	GRPC        GRPC
+	API         API
+	Auth        Auth
+	Hash        Hash
+	Trash       Trash
+	Search      Search
+	Attachments Attachments
+	Storage     Storage
+	Audit       Audit
+	Idempotency Idempotency
+	Events      Events
+	Webhooks    Webhooks
+	RateLimit   RateLimit `mapstructure:"rate_limit"`
+	CORS        CORS
+	Security    Security
+	Metrics     Metrics
+	Tracing     Tracing
 }
 
 func New(dirname, filename string) (*Config, error) {
@@ -67,5 +220,80 @@ func New(dirname, filename string) (*Config, error) {
 		return nil, err
 	}
 
+	if err := envconfig.Process("attachments", &cfg.Attachments); err != nil {
+		return nil, err
+	}
+
+	if err := envconfig.Process("s3", &cfg.Storage.S3); err != nil {
+		return nil, err
+	}
+
+	// Origins and security headers differ per environment, so they can be
+	// overridden with CORS_* and SECURITY_* variables.
+	if err := envconfig.Process("cors", &cfg.CORS); err != nil {
+		return nil, err
+	}
+
+	if err := envconfig.Process("security", &cfg.Security); err != nil {
+		return nil, err
+	}
+
+	// The proxies in front of the server differ per environment too.
+	if err := envconfig.Process("server", &cfg.Server); err != nil {
+		return nil, err
+	}
+
+	if err := envconfig.Process("tracing", &cfg.Tracing); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
 	return cfg, nil
 }
+
+// validate checks the settings there's no sensible zero value for: the
+// intervals the background jobs tick with, the trash retention and the
+// download signing key, and that the trusted proxies parse.
+func (c *Config) validate() error {
+	if len(c.Attachments.SigningKey) == 0 {
+		return errors.New("attachments.signing_key is required, set ATTACHMENTS_SIGNING_KEY")
+	}
+
+	intervals := []struct {
+		key      string
+		interval time.Duration
+	}{
+		{"trash.purge_interval", c.Trash.PurgeInterval},
+		{"attachments.cleanup_interval", c.Attachments.CleanupInterval},
+		{"idempotency.cleanup_interval", c.Idempotency.CleanupInterval},
+		{"webhooks.delivery_interval", c.Webhooks.DeliveryInterval},
+		{"rate_limit.cleanup_interval", c.RateLimit.CleanupInterval},
+	}
+
+	for _, i := range intervals {
+		if i.interval <= 0 {
+			return fmt.Errorf("%s must be positive, got %s", i.key, i.interval)
+		}
+	}
+
+	for _, proxy := range c.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return fmt.Errorf("server.trusted_proxies: %q is neither an ip nor a cidr", proxy)
+		}
+	}
+
+	// Purging runs with now - retention as the cutoff, and the retention is
+	// also how long sync tokens live, so zero would empty the trash.
+	if c.Trash.Retention <= 0 {
+		return fmt.Errorf("trash.retention must be positive, got %s", c.Trash.Retention)
+	}
+
+	if c.Idempotency.Lease <= c.DB.Timeout {
+		return fmt.Errorf("idempotency.lease must be longer than db.timeout, got %s", c.Idempotency.Lease)
+	}
+
+	return nil
+}
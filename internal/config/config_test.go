@@ -0,0 +1,87 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies []string
+		wantErr bool
+	}{
+		{name: "none"},
+		{name: "addresses", proxies: []string{"10.0.0.1", "::1"}},
+		{name: "cidrs", proxies: []string{"10.0.0.0/8", "fd00::/8"}},
+		{name: "host name", proxies: []string{"proxy.internal"}, wantErr: true},
+		{name: "bad cidr", proxies: []string{"10.0.0.0/33"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			cfg.Server.TrustedProxies = tt.proxies
+
+			err := cfg.validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got %v, want error: %v", err, tt.wantErr)
+			}
+
+			if err != nil && !strings.Contains(err.Error(), "trusted_proxies") {
+				t.Errorf("got %v, want it to name the setting", err)
+			}
+		})
+	}
+}
+
+func validConfig() *Config {
+	cfg := &Config{}
+	cfg.Attachments.SigningKey = []byte("key")
+	cfg.Trash.PurgeInterval = time.Hour
+	cfg.Trash.Retention = 720 * time.Hour
+	cfg.Attachments.CleanupInterval = time.Hour
+	cfg.Idempotency.CleanupInterval = time.Hour
+	cfg.Webhooks.DeliveryInterval = time.Second
+	cfg.RateLimit.CleanupInterval = time.Minute
+	cfg.DB.Timeout = 5 * time.Second
+	cfg.Idempotency.Lease = 30 * time.Second
+
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(cfg *Config)
+		wantErr string
+	}{
+		{name: "valid", change: func(cfg *Config) {}},
+		{name: "no signing key", change: func(cfg *Config) { cfg.Attachments.SigningKey = nil }, wantErr: "attachments.signing_key"},
+		{name: "no purge interval", change: func(cfg *Config) { cfg.Trash.PurgeInterval = 0 }, wantErr: "trash.purge_interval"},
+		{name: "no delivery interval", change: func(cfg *Config) { cfg.Webhooks.DeliveryInterval = 0 }, wantErr: "webhooks.delivery_interval"},
+		{name: "no trash retention", change: func(cfg *Config) { cfg.Trash.Retention = 0 }, wantErr: "trash.retention"},
+		{name: "negative trash retention", change: func(cfg *Config) { cfg.Trash.Retention = -time.Hour }, wantErr: "trash.retention"},
+		{name: "lease within db timeout", change: func(cfg *Config) { cfg.Idempotency.Lease = cfg.DB.Timeout }, wantErr: "idempotency.lease"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.change(cfg)
+
+			err := cfg.validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("got %v, want no error", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got %v, want an error about %s", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrAttachmentTooLarge   = errors.New("attachment is too large")
+	ErrUnsupportedMediaType = errors.New("unsupported attachment type")
+	ErrInvalidSignature     = errors.New("invalid or expired download signature")
+)
+
+type Attachment struct {
+	Id          int       `json:"id"`
+	ItemId      int       `json:"item_id"`
+	UserId      int       `json:"user_id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	StorageKey  string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+	URL         string    `json:"url,omitempty"`
+}
@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrUndoUnavailable = errors.New("event not found or can no longer be undone")
+	ErrEntityChanged   = errors.New("entity has changed since the event")
+	ErrVersionMismatch = errors.New("entity version does not match")
+)
+
+const (
+	EntityTypeList = "list"
+	EntityTypeItem = "item"
+)
+
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+	AuditActionMove    = "move"
+	AuditActionUndo    = "undo"
+	// AuditActionAddMember and AuditActionRemoveMember record list sharing,
+	// with the member's user_id as the field.
+	AuditActionAddMember    = "add_member"
+	AuditActionRemoveMember = "remove_member"
+)
+
+// AuditFields holds entity field values by name, e.g. "title", "done",
+// "archived", "deleted" or "list_id".
+type AuditFields map[string]interface{}
+
+type AuditEvent struct {
+	Id         int         `json:"id"`
+	EntityType string      `json:"entity_type"`
+	EntityId   int         `json:"entity_id"`
+	ActorId    *int        `json:"actor_id"`
+	Action     string      `json:"action"`
+	Before     AuditFields `json:"before,omitempty"`
+	After      AuditFields `json:"after,omitempty"`
+	RequestId  string      `json:"request_id,omitempty"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+type requestIdKey struct{}
+
+// WithRequestId returns a copy of ctx carrying the id of the request it
+// serves, which is recorded with the request's audit events.
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, requestId)
+}
+
+func RequestIdFromContext(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdKey{}).(string)
+	return requestId
+}
+
+type AuditFilter struct {
+	ActorId    *int
+	EntityType string
+	EntityId   *int
+	Action     string
+	From       *time.Time
+	To         *time.Time
+	Pagination
+}
@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	BulkOpUpdate   = "update"
+	BulkOpDelete   = "delete"
+	BulkOpMove     = "move"
+	BulkOpComplete = "complete"
+)
+
+const (
+	// BulkModeAtomic applies every operation or none of them.
+	BulkModeAtomic = "atomic"
+	// BulkModePartial applies the operations that succeed and reports the rest.
+	BulkModePartial = "partial"
+)
+
+const (
+	BulkStatusOk         = "ok"
+	BulkStatusFailed     = "failed"
+	BulkStatusRolledBack = "rolled_back"
+	BulkStatusSkipped    = "skipped"
+)
+
+const MaxBulkOperations = 100
+
+var (
+	ErrInvalidBulk = errors.New("invalid bulk request")
+	ErrBulkFailed  = errors.New("bulk operation failed, no changes were applied")
+)
+
+type BulkOperation struct {
+	Op     string `json:"op" binding:"required"`
+	ItemId int    `json:"item_id" binding:"required"`
+	// Update holds the fields to change for the update operation.
+	Update *UpdateItemInput `json:"update,omitempty"`
+	// ListId is the target list of the move operation.
+	ListId int `json:"list_id,omitempty"`
+}
+
+func (o BulkOperation) Validate() error {
+	switch o.Op {
+	case BulkOpUpdate:
+		if o.Update == nil {
+			return errors.New("update requires update values")
+		}
+		return o.Update.Validate()
+	case BulkOpMove:
+		if o.ListId <= 0 {
+			return errors.New("move requires list_id")
+		}
+	case BulkOpDelete, BulkOpComplete:
+	default:
+		return fmt.Errorf("unknown op %q", o.Op)
+	}
+
+	return nil
+}
+
+type BulkInput struct {
+	Mode       string          `json:"mode" enums:"atomic,partial" default:"atomic"`
+	Operations []BulkOperation `json:"operations" binding:"required"`
+}
+
+func (i *BulkInput) Validate() error {
+	if i.Mode == "" {
+		i.Mode = BulkModeAtomic
+	}
+
+	if i.Mode != BulkModeAtomic && i.Mode != BulkModePartial {
+		return fmt.Errorf("%w: unknown mode %q", ErrInvalidBulk, i.Mode)
+	}
+
+	if len(i.Operations) == 0 {
+		return fmt.Errorf("%w: no operations", ErrInvalidBulk)
+	}
+
+	if len(i.Operations) > MaxBulkOperations {
+		return fmt.Errorf("%w: at most %d operations are allowed", ErrInvalidBulk, MaxBulkOperations)
+	}
+
+	for idx, op := range i.Operations {
+		if err := op.Validate(); err != nil {
+			return fmt.Errorf("%w: operation %d: %s", ErrInvalidBulk, idx, err)
+		}
+	}
+
+	return nil
+}
+
+// ListIds returns the target lists of the move operations.
+func (i BulkInput) ListIds() []int {
+	seen := make(map[int]bool)
+	ids := make([]int, 0)
+
+	for _, op := range i.Operations {
+		if op.Op == BulkOpMove && !seen[op.ListId] {
+			seen[op.ListId] = true
+			ids = append(ids, op.ListId)
+		}
+	}
+
+	return ids
+}
+
+// ItemIds returns the items the operations touch.
+func (i BulkInput) ItemIds() []int {
+	seen := make(map[int]bool)
+	ids := make([]int, 0)
+
+	for _, op := range i.Operations {
+		if !seen[op.ItemId] {
+			seen[op.ItemId] = true
+			ids = append(ids, op.ItemId)
+		}
+	}
+
+	return ids
+}
+
+type BulkResult struct {
+	Op     string `json:"op"`
+	ItemId int    `json:"item_id"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type BulkResponse struct {
+	Mode    string       `json:"mode"`
+	Results []BulkResult `json:"results"`
+}
@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+const NotificationTypeMention = "mention"
+
+// mentionRegexp matches @ followed by an email address, the only unique
+// name a user has.
+var mentionRegexp = regexp.MustCompile(`(?:^|[^\w@])@([\w.+\-]+@[\w\-]+(?:\.[\w\-]+)+)`)
+
+type Comment struct {
+	Id        int       `json:"id"`
+	ItemId    int       `json:"item_id"`
+	UserId    int       `json:"user_id"`
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CommentInput struct {
+	Body string `json:"body" binding:"required"`
+}
+
+// Mentions returns the lowercased emails mentioned as @email in the comment
+// body, e.g. @alice@example.com.
+func (i CommentInput) Mentions() []string {
+	seen := make(map[string]bool)
+	emails := make([]string, 0)
+
+	for _, m := range mentionRegexp.FindAllStringSubmatch(i.Body, -1) {
+		email := strings.ToLower(m[1])
+		if seen[email] {
+			continue
+		}
+
+		seen[email] = true
+		emails = append(emails, email)
+	}
+
+	return emails
+}
+
+type Notification struct {
+	Id        int       `json:"id"`
+	Type      string    `json:"type"`
+	ActorId   int       `json:"actor_id"`
+	ItemId    *int      `json:"item_id"`
+	CommentId *int      `json:"comment_id"`
+	Read      bool      `json:"read"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+// MaxReplayEvents caps how many missed events a reconnecting client is sent.
+const MaxReplayEvents = 500
+
+// ChangeEvent is a list or item change streamed to the users of its lists.
+type ChangeEvent struct {
+	Id         int         `json:"id"`
+	EntityType string      `json:"entity_type"`
+	EntityId   int         `json:"entity_id"`
+	ActorId    *int        `json:"actor_id"`
+	Action     string      `json:"action"`
+	Fields     AuditFields `json:"fields,omitempty"`
+	// ListIds holds the list itself, or the lists an item is or was in.
+	ListIds   []int     `json:"list_ids"`
+	CreatedAt time.Time `json:"created_at"`
+	// UserIds holds the users that can access the lists.
+	UserIds []int `json:"-"`
+}
@@ -0,0 +1,13 @@
+package domain
+
+const (
+	HealthStatusOK          = "ok"
+	HealthStatusUnavailable = "unavailable"
+)
+
+// HealthReport is the result of a health check, Checks holds "ok" or the
+// error of each dependency.
+type HealthReport struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
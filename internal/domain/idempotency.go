@@ -0,0 +1,25 @@
+package domain
+
+import "errors"
+
+const MaxIdempotencyKeyLength = 255
+
+var (
+	ErrInvalidIdempotencyKey = errors.New("invalid idempotency key")
+	ErrIdempotencyKeyReused  = errors.New("idempotency key was already used for a different request")
+	ErrRequestInProgress     = errors.New("a request with this idempotency key is still in progress")
+)
+
+// StoredResponse is the response replayed for a repeated idempotency key.
+type StoredResponse struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+// IdempotencyRecord is what is already stored for an idempotency key.
+// Response is nil while the first request is still in progress.
+type IdempotencyRecord struct {
+	Fingerprint string
+	Response    *StoredResponse
+}
@@ -0,0 +1,27 @@
+package domain
+
+import "errors"
+
+const (
+	ListRoleOwner  = "owner"
+	ListRoleMember = "member"
+)
+
+var (
+	ErrNotListOwner = errors.New("only the list owner can do this")
+	ErrListOwner    = errors.New("the list owner can't be removed")
+)
+
+// ListMember is a user the list is shared with. Every member has full
+// access to the list, its items and comments, the owner alone can remove
+// other members and delete the list.
+type ListMember struct {
+	UserId int    `json:"user_id"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+}
+
+type AddMemberInput struct {
+	Email string `json:"email" binding:"required"`
+}
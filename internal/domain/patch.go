@@ -0,0 +1,30 @@
+package domain
+
+import "errors"
+
+const (
+	MergePatchContentType = "application/merge-patch+json"
+	JSONPatchContentType  = "application/json-patch+json"
+)
+
+var (
+	ErrUnsupportedPatchType = errors.New("unsupported patch content type")
+	ErrInvalidPatch         = errors.New("patch can't be applied")
+	ErrPatchConflict        = errors.New("entity kept changing while the patch was applied, retry later")
+)
+
+// Patch is a JSON Merge Patch (RFC 7396) or JSON Patch (RFC 6902) document.
+type Patch struct {
+	ContentType string
+	Body        []byte
+	// Version, when set, must match the current entity version.
+	Version VersionMatch
+}
+
+func (p Patch) Validate() error {
+	if p.ContentType != MergePatchContentType && p.ContentType != JSONPatchContentType {
+		return ErrUnsupportedPatchType
+	}
+
+	return nil
+}
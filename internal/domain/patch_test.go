@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPatchValidate(t *testing.T) {
+	tests := []struct {
+		contentType string
+		wantErr     error
+	}{
+		{contentType: MergePatchContentType},
+		{contentType: JSONPatchContentType},
+		{contentType: "application/json", wantErr: ErrUnsupportedPatchType},
+		{contentType: "", wantErr: ErrUnsupportedPatchType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			if err := (Patch{ContentType: tt.contentType}).Validate(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReplaceInputValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		title   string
+		wantErr bool
+	}{
+		{name: "title", title: "groceries"},
+		{name: "padded title", title: "  groceries "},
+		{name: "empty title", title: "", wantErr: true},
+		{name: "blank title", title: " \t\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := (ReplaceListInput{Title: tt.title}).Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("ReplaceListInput: got %v, want error: %v", err, tt.wantErr)
+			}
+
+			if err := (ReplaceItemInput{Title: tt.title}).Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("ReplaceItemInput: got %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// RateLimit is a token bucket holding up to Burst requests, refilled at
+// Limit requests per Period.
+type RateLimit struct {
+	Limit  int
+	Period time.Duration
+	Burst  int
+}
+
+// Rate is the refill rate in requests per second.
+func (l RateLimit) Rate() float64 {
+	return float64(l.Limit) / l.Period.Seconds()
+}
+
+// Refill returns the tokens of a bucket after elapsed time.
+func (l RateLimit) Refill(tokens float64, elapsed time.Duration) float64 {
+	return math.Min(float64(l.Burst), tokens+elapsed.Seconds()*l.Rate())
+}
+
+// FullAfter is how long an empty bucket takes to refill.
+func (l RateLimit) FullAfter() time.Duration {
+	return time.Duration(float64(l.Burst) / l.Rate() * float64(time.Second))
+}
+
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is how long until the next request is allowed.
+	RetryAfter time.Duration
+	// ResetAfter is how long until the bucket is full again.
+	ResetAfter time.Duration
+}
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimitRefill(t *testing.T) {
+	// 60 requests a minute is one a second, in bursts of up to 10.
+	limit := RateLimit{Limit: 60, Period: time.Minute, Burst: 10}
+
+	tests := []struct {
+		name    string
+		tokens  float64
+		elapsed time.Duration
+		want    float64
+	}{
+		{name: "no time passed", tokens: 3, elapsed: 0, want: 3},
+		{name: "one second", tokens: 3, elapsed: time.Second, want: 4},
+		{name: "part of a second", tokens: 0, elapsed: 250 * time.Millisecond, want: 0.25},
+		{name: "refills up to the burst", tokens: 8, elapsed: time.Hour, want: 10},
+		{name: "full stays full", tokens: 10, elapsed: time.Second, want: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := limit.Refill(tt.tokens, tt.elapsed); got != tt.want {
+				t.Errorf("Refill(%v, %v) = %v, want %v", tt.tokens, tt.elapsed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitFullAfter(t *testing.T) {
+	tests := []struct {
+		limit RateLimit
+		want  time.Duration
+	}{
+		{limit: RateLimit{Limit: 60, Period: time.Minute, Burst: 10}, want: 10 * time.Second},
+		{limit: RateLimit{Limit: 10, Period: time.Minute, Burst: 5}, want: 30 * time.Second},
+		{limit: RateLimit{Limit: 100, Period: time.Second, Burst: 1}, want: 10 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		if got := tt.limit.FullAfter(); got != tt.want {
+			t.Errorf("%+v: FullAfter() = %v, want %v", tt.limit, got, tt.want)
+		}
+	}
+}
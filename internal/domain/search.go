@@ -0,0 +1,42 @@
+package domain
+
+import "errors"
+
+const (
+	SearchDefaultLimit = 20
+	SearchMaxLimit     = 100
+)
+
+type SearchInput struct {
+	Query  string
+	ListId *int
+	Done   *bool
+	Limit  int
+}
+
+func (i *SearchInput) Validate() error {
+	if i.Query == "" {
+		return errors.New("search query is empty")
+	}
+
+	if i.Limit <= 0 {
+		i.Limit = SearchDefaultLimit
+	}
+
+	if i.Limit > SearchMaxLimit {
+		i.Limit = SearchMaxLimit
+	}
+
+	return nil
+}
+
+type SearchHit struct {
+	Type   string `json:"type"`
+	Id     int    `json:"id"`
+	ListId int    `json:"list_id"`
+	Title  string `json:"title"`
+	// Snippet is HTML: the matched text, escaped, with the matches wrapped
+	// in <mark> tags.
+	Snippet string  `json:"snippet"`
+	Rank    float64 `json:"rank"`
+}
@@ -0,0 +1,178 @@
+package domain
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultSyncLimit = 500
+	MaxSyncLimit     = 1000
+	MaxSyncChanges   = 100
+)
+
+const (
+	SyncOpUpsert = "upsert"
+	SyncOpDelete = "delete"
+)
+
+const (
+	SyncStatusApplied  = "applied"
+	SyncStatusConflict = "conflict"
+	SyncStatusError    = "error"
+)
+
+var (
+	ErrInvalidSyncToken = errors.New("invalid sync token")
+	// ErrSyncTokenExpired means deletions since the token may have been
+	// forgotten and the client has to sync from scratch.
+	ErrSyncTokenExpired = errors.New("sync token expired, sync from scratch")
+	ErrInvalidSync      = errors.New("invalid sync request")
+)
+
+// SyncCursor is a position in the change stream. Changes are ordered by the
+// id of the transaction that made them, then by their change sequence number.
+type SyncCursor struct {
+	Xid      uint64
+	Seq      int64
+	IssuedAt time.Time
+}
+
+func (c SyncCursor) Token() string {
+	raw := fmt.Sprintf("1.%d.%d.%d", c.Xid, c.Seq, c.IssuedAt.Unix())
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func ParseSyncToken(token string) (SyncCursor, error) {
+	var cursor SyncCursor
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, ErrInvalidSyncToken
+	}
+
+	parts := strings.Split(string(raw), ".")
+	if len(parts) != 4 || parts[0] != "1" {
+		return cursor, ErrInvalidSyncToken
+	}
+
+	cursor.Xid, err = strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return cursor, ErrInvalidSyncToken
+	}
+
+	cursor.Seq, err = strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return cursor, ErrInvalidSyncToken
+	}
+
+	issuedAt, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil {
+		return cursor, ErrInvalidSyncToken
+	}
+	cursor.IssuedAt = time.Unix(issuedAt, 0)
+
+	return cursor, nil
+}
+
+// SyncRecord is the current state of a list or item, or its tombstone.
+type SyncRecord struct {
+	EntityType string    `json:"entity_type"`
+	Id         int       `json:"id"`
+	Deleted    bool      `json:"deleted"`
+	ListId     *int      `json:"list_id,omitempty"`
+	List       *TodoList `json:"list,omitempty"`
+	Item       *TodoItem `json:"item,omitempty"`
+	// Cursor is the record's position in the change stream.
+	Cursor SyncCursor `json:"-"`
+}
+
+type SyncChanges struct {
+	Changes []SyncRecord `json:"changes"`
+	// Token is passed as since to get the changes that follow.
+	Token string `json:"token"`
+	// HasMore means the changes were cut at the limit and the client should
+	// request the next ones right away.
+	HasMore bool `json:"has_more"`
+}
+
+// ClientChange is a change a client made offline. Creates have no id and are
+// matched to their result by client_id, items created in a list the batch
+// also creates refer to it by list_client_id.
+type ClientChange struct {
+	ClientId     string  `json:"client_id"`
+	EntityType   string  `json:"entity_type" binding:"required"`
+	Op           string  `json:"op" binding:"required"`
+	Id           int     `json:"id"`
+	BaseVersion  *int    `json:"base_version"`
+	ListId       int     `json:"list_id"`
+	ListClientId string  `json:"list_client_id"`
+	Title        string  `json:"title"`
+	Description  *string `json:"description"`
+	Done         bool    `json:"done"`
+	IsTemplate   bool    `json:"is_template"`
+}
+
+func (c ClientChange) Validate() error {
+	if c.EntityType != EntityTypeList && c.EntityType != EntityTypeItem {
+		return fmt.Errorf("unknown entity type %q", c.EntityType)
+	}
+
+	switch c.Op {
+	case SyncOpUpsert:
+		if strings.TrimSpace(c.Title) == "" {
+			return errors.New("title is required")
+		}
+
+		if c.Id == 0 && c.ClientId == "" {
+			return errors.New("client_id is required to create")
+		}
+
+		if c.Id == 0 && c.EntityType == EntityTypeItem && c.ListId == 0 && c.ListClientId == "" {
+			return errors.New("list_id or list_client_id is required to create an item")
+		}
+	case SyncOpDelete:
+		if c.Id == 0 {
+			return errors.New("id is required to delete")
+		}
+	default:
+		return fmt.Errorf("unknown op %q", c.Op)
+	}
+
+	return nil
+}
+
+type SyncInput struct {
+	Changes []ClientChange `json:"changes" binding:"required"`
+}
+
+func (i SyncInput) Validate() error {
+	if len(i.Changes) > MaxSyncChanges {
+		return fmt.Errorf("%w: at most %d changes are allowed", ErrInvalidSync, MaxSyncChanges)
+	}
+
+	for idx, change := range i.Changes {
+		if err := change.Validate(); err != nil {
+			return fmt.Errorf("%w: change %d: %s", ErrInvalidSync, idx, err)
+		}
+	}
+
+	return nil
+}
+
+// SyncResult reports what happened to a client change. On conflict Current
+// holds the server's version of the record, which is nil or deleted if the
+// record is gone.
+type SyncResult struct {
+	ClientId   string      `json:"client_id,omitempty"`
+	EntityType string      `json:"entity_type"`
+	Id         int         `json:"id"`
+	Status     string      `json:"status"`
+	Version    int         `json:"version,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	Current    *SyncRecord `json:"current,omitempty"`
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"strings"
+)
 
 type ListsItem struct {
 	Id     int
@@ -9,16 +12,19 @@ type ListsItem struct {
 }
 
 type TodoItem struct {
-	Id          int    `json:"id"`
-	Title       string `json:"title" binding:"required"`
-	Description string `json:"description"`
-	Done        bool   `json:"done"`
+	Id          int     `json:"id"`
+	Title       string  `json:"title" binding:"required"`
+	Description *string `json:"description"`
+	Done        bool    `json:"done"`
+	Version     int     `json:"version"`
 }
 
 type UpdateItemInput struct {
 	Title       *string `json:"title"`
 	Description *string `json:"description"`
 	Done        *bool   `json:"done"`
+	// Version, when set, must match the current item version.
+	Version VersionMatch `json:"-"`
 }
 
 func (i UpdateItemInput) Validate() error {
@@ -28,3 +34,38 @@ func (i UpdateItemInput) Validate() error {
 
 	return nil
 }
+
+// ReplaceItemInput holds every writable item field, PUT replaces the
+// item with it and PATCH applies the patch to it.
+type ReplaceItemInput struct {
+	Title       string  `json:"title" binding:"required"`
+	Description *string `json:"description"`
+	Done        bool    `json:"done"`
+	// Version, when set, must match the current item version.
+	Version VersionMatch `json:"-"`
+}
+
+func (i ReplaceItemInput) Validate() error {
+	if strings.TrimSpace(i.Title) == "" {
+		return errors.New("title is required")
+	}
+
+	return nil
+}
+
+type MoveItemInput struct {
+	ListId int `json:"list_id" binding:"required"`
+}
+
+type MoveItemsInput struct {
+	ItemIds []int `json:"item_ids" binding:"required"`
+	ListId  int   `json:"list_id" binding:"required"`
+}
+
+func (i MoveItemsInput) Validate() error {
+	if len(i.ItemIds) == 0 {
+		return errors.New("no items to move")
+	}
+
+	return nil
+}
@@ -1,6 +1,12 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrNotTemplate = errors.New("list is not a template")
 
 type UsersList struct {
 	Id     int
@@ -9,20 +15,70 @@ type UsersList struct {
 }
 
 type TodoList struct {
-	Id          int    `json:"id"`
-	Title       string `json:"title" binding:"required"`
-	Description string `json:"description"`
+	Id          int     `json:"id"`
+	Title       string  `json:"title" binding:"required"`
+	Description *string `json:"description"`
+	IsTemplate  bool    `json:"is_template"`
+	Archived    bool    `json:"archived"`
+	Version     int     `json:"version"`
 }
 
 type UpdateListInput struct {
 	Title       *string `json:"title"`
 	Description *string `json:"description"`
+	IsTemplate  *bool   `json:"is_template"`
+	// Version, when set, must match the current list version.
+	Version VersionMatch `json:"-"`
 }
 
 func (i UpdateListInput) Validate() error {
-	if i.Title == nil && i.Description == nil {
+	if i.Title == nil && i.Description == nil && i.IsTemplate == nil {
 		return errors.New("update structure has no values")
 	}
 
 	return nil
 }
+
+// ReplaceListInput holds every writable list field, PUT replaces the
+// list with it and PATCH applies the patch to it.
+type ReplaceListInput struct {
+	Title       string  `json:"title" binding:"required"`
+	Description *string `json:"description"`
+	IsTemplate  bool    `json:"is_template"`
+	// Version, when set, must match the current list version.
+	Version VersionMatch `json:"-"`
+}
+
+func (i ReplaceListInput) Validate() error {
+	if strings.TrimSpace(i.Title) == "" {
+		return errors.New("title is required")
+	}
+
+	return nil
+}
+
+type DuplicateListInput struct {
+	Title     *string `json:"title"`
+	ResetDone bool    `json:"reset_done"`
+}
+
+type FromTemplateInput struct {
+	Values    map[string]string `json:"values"`
+	ResetDone bool              `json:"reset_done"`
+}
+
+// Substitute replaces {{key}} placeholders in s with the input values.
+// {{date}} defaults to the current date when no value is given.
+func (i FromTemplateInput) Substitute(s string) string {
+	values := map[string]string{"date": time.Now().Format("2006-01-02")}
+	for k, v := range i.Values {
+		values[k] = v
+	}
+
+	pairs := make([]string, 0, len(values)*2)
+	for k, v := range values {
+		pairs = append(pairs, "{{"+k+"}}", v)
+	}
+
+	return strings.NewReplacer(pairs...).Replace(s)
+}
@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	TrashTypeList = EntityTypeList
+	TrashTypeItem = EntityTypeItem
+)
+
+type TrashEntry struct {
+	Type      string    `json:"type"`
+	Id        int       `json:"id"`
+	Title     string    `json:"title"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+func ValidateTrashType(entityType string) error {
+	if entityType != TrashTypeList && entityType != TrashTypeItem {
+		return errors.New("unknown trash entry type")
+	}
+
+	return nil
+}
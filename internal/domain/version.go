@@ -0,0 +1,21 @@
+package domain
+
+import "slices"
+
+// VersionMatch is the precondition of a write: the entity's current
+// version must be one of its versions. A nil VersionMatch matches any
+// version, an empty one matches none.
+type VersionMatch []int
+
+// MatchVersion requires the given version, or nothing when it's nil.
+func MatchVersion(version *int) VersionMatch {
+	if version == nil {
+		return nil
+	}
+
+	return VersionMatch{*version}
+}
+
+func (m VersionMatch) Matches(version int) bool {
+	return m == nil || slices.Contains(m, version)
+}
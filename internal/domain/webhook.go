@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"slices"
+	"time"
+)
+
+const WebhookEventPing = "ping"
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+var (
+	ErrInvalidWebhook = errors.New("invalid webhook")
+	// ErrWebhookAddress is returned when a webhook url points into the
+	// server's own network.
+	ErrWebhookAddress = errors.New("webhook address not allowed")
+)
+
+// WebhookEventTypes returns the event types webhooks can subscribe to,
+// named "<entity type>.<audit action>".
+func WebhookEventTypes() []string {
+	types := make([]string, 0)
+
+	for _, entityType := range []string{EntityTypeList, EntityTypeItem} {
+		for _, action := range []string{AuditActionCreate, AuditActionUpdate, AuditActionDelete,
+			AuditActionRestore, AuditActionMove, AuditActionUndo} {
+			if entityType == EntityTypeList && action == AuditActionMove {
+				continue
+			}
+
+			types = append(types, entityType+"."+action)
+		}
+	}
+
+	types = append(types, EntityTypeList+"."+AuditActionAddMember, EntityTypeList+"."+AuditActionRemoveMember)
+
+	return types
+}
+
+type Webhook struct {
+	Id           int       `json:"id"`
+	URL          string    `json:"url"`
+	EventTypes   []string  `json:"event_types"`
+	ListId       *int      `json:"list_id"`
+	Active       bool      `json:"active"`
+	FailureCount int       `json:"failure_count"`
+	CreatedAt    time.Time `json:"created_at"`
+	// Secret is only returned when the webhook is created.
+	Secret string `json:"secret,omitempty"`
+}
+
+type WebhookInput struct {
+	URL string `json:"url" binding:"required"`
+	// EventTypes subscribes to every event type when empty.
+	EventTypes []string `json:"event_types"`
+	// ListId limits the webhook to one list.
+	ListId *int `json:"list_id"`
+	// Active re-enables a webhook disabled after repeated failures.
+	Active *bool `json:"active"`
+}
+
+func (i WebhookInput) Validate() error {
+	u, err := url.Parse(i.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https url", ErrInvalidWebhook)
+	}
+
+	// Host names are checked when the delivery connects, as they can resolve
+	// to another address by then.
+	if addr, err := netip.ParseAddr(u.Hostname()); (err == nil && !WebhookAddressAllowed(addr)) ||
+		u.Hostname() == "localhost" {
+		return fmt.Errorf("%w: %w", ErrInvalidWebhook, ErrWebhookAddress)
+	}
+
+	known := WebhookEventTypes()
+	for _, eventType := range i.EventTypes {
+		if !slices.Contains(known, eventType) {
+			return fmt.Errorf("%w: unknown event type %q", ErrInvalidWebhook, eventType)
+		}
+	}
+
+	return nil
+}
+
+// WebhookAddressAllowed reports whether webhooks may be sent to addr, which
+// excludes loopback, link-local, private, multicast and unspecified addresses.
+func WebhookAddressAllowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	return addr.IsValid() && !addr.IsLoopback() && !addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() && !addr.IsInterfaceLocalMulticast() && !addr.IsMulticast() &&
+		!addr.IsPrivate() && !addr.IsUnspecified()
+}
+
+type WebhookDelivery struct {
+	Id            int        `json:"id"`
+	WebhookId     int        `json:"webhook_id"`
+	EventId       *int       `json:"event_id"`
+	EventType     string     `json:"event_type"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	ResponseCode  *int       `json:"response_code"`
+	Error         *string    `json:"error"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	DeliveredAt   *time.Time `json:"delivered_at"`
+}
+
+// PendingDelivery is a delivery claimed for sending.
+type PendingDelivery struct {
+	WebhookDelivery
+	URL     string
+	Secret  string
+	Payload []byte
+}
+
+// DeliveryResult is the outcome of one attempt to send a delivery.
+type DeliveryResult struct {
+	ResponseCode *int
+	Error        string
+}
+
+func (r DeliveryResult) Succeeded() bool {
+	return r.Error == "" && r.ResponseCode != nil && *r.ResponseCode >= 200 && *r.ResponseCode < 300
+}
+
+// WebhookPayload is the body posted to webhooks.
+type WebhookPayload struct {
+	DeliveryId int          `json:"delivery_id"`
+	EventType  string       `json:"event_type"`
+	Event      *ChangeEvent `json:"event,omitempty"`
+	SentAt     time.Time    `json:"sent_at"`
+}
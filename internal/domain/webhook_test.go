@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWebhookInputValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		eventTypes []string
+		wantErr    error
+	}{
+		{name: "https", url: "https://example.com/hook"},
+		{name: "public address", url: "http://93.184.216.34:8080/hook"},
+		{name: "event types", url: "https://example.com/hook", eventTypes: []string{"item.create", WebhookEventTypes()[0]}},
+		{name: "relative", url: "/hook", wantErr: ErrInvalidWebhook},
+		{name: "ftp", url: "ftp://example.com/hook", wantErr: ErrInvalidWebhook},
+		{name: "unknown event type", url: "https://example.com/hook", eventTypes: []string{"item.eat"}, wantErr: ErrInvalidWebhook},
+		{name: "localhost", url: "http://localhost:8000/hook", wantErr: ErrWebhookAddress},
+		{name: "loopback", url: "http://127.0.0.1/hook", wantErr: ErrWebhookAddress},
+		{name: "ipv6 loopback", url: "http://[::1]/hook", wantErr: ErrWebhookAddress},
+		{name: "mapped loopback", url: "http://[::ffff:127.0.0.1]/hook", wantErr: ErrWebhookAddress},
+		{name: "link-local", url: "http://169.254.169.254/latest", wantErr: ErrWebhookAddress},
+		{name: "private", url: "http://192.168.1.10/hook", wantErr: ErrWebhookAddress},
+		{name: "unspecified", url: "http://0.0.0.0/hook", wantErr: ErrWebhookAddress},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := WebhookInput{URL: tt.url, EventTypes: tt.eventTypes}.Validate()
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil && !errors.Is(err, ErrInvalidWebhook) {
+				t.Errorf("got %v, want it to wrap %v", err, ErrInvalidWebhook)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// collectTimeout bounds the queries of a scrape.
+const collectTimeout = 5 * time.Second
+
+type Stats interface {
+	GetActiveUsers(ctx context.Context, window time.Duration) (int, error)
+	GetItemsCreated(ctx context.Context, window time.Duration) (int, error)
+}
+
+// BusinessCollector reports the active users and created items of the last
+// window, counted by the database on every scrape so that all instances
+// report the same values.
+type BusinessCollector struct {
+	stats  Stats
+	window time.Duration
+
+	activeUsers  *prometheus.Desc
+	itemsCreated *prometheus.Desc
+}
+
+func NewBusinessCollector(stats Stats, window time.Duration) *BusinessCollector {
+	return &BusinessCollector{
+		stats:  stats,
+		window: window,
+		activeUsers: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "active_users"),
+			fmt.Sprintf("Users who changed lists or items in the last %s.", window), nil, nil),
+		itemsCreated: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "items_created"),
+			fmt.Sprintf("Items created in the last %s.", window), nil, nil),
+	}
+}
+
+func (c *BusinessCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.activeUsers
+	ch <- c.itemsCreated
+}
+
+// Collect skips the gauges that failed to load, a failing database
+// shouldn't fail the whole scrape.
+func (c *BusinessCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	if users, err := c.stats.GetActiveUsers(ctx, c.window); err != nil {
+		logrus.WithField("metric", "active_users").Error(err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.activeUsers, prometheus.GaugeValue, float64(users))
+	}
+
+	if items, err := c.stats.GetItemsCreated(ctx, c.window); err != nil {
+		logrus.WithField("metric", "items_created").Error(err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.itemsCreated, prometheus.GaugeValue, float64(items))
+	}
+}
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "crud_app"
+
+const (
+	AuthSignUp         = "sign_up"
+	AuthSignIn         = "sign_in"
+	AuthSignInFailure  = "sign_in_failure"
+	AuthRefresh        = "refresh"
+	AuthRefreshFailure = "refresh_failure"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by method, route template and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by method, route template and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	queryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Duration of repository methods, including their transactions.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"repository", "method"})
+
+	authEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "auth",
+		Name:      "events_total",
+		Help:      "Sign-ups, sign-ins and token refreshes, and their failures.",
+	}, []string{"event"})
+)
+
+func init() {
+	for _, event := range []string{AuthSignUp, AuthSignIn, AuthSignInFailure, AuthRefresh, AuthRefreshFailure} {
+		authEvents.WithLabelValues(event)
+	}
+}
+
+// ObserveHTTP records a handled request. route is the route template, e.g.
+// "/api/lists/:id", so that ids don't end up in labels.
+func ObserveHTTP(method, route string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+
+	httpRequests.WithLabelValues(method, route, code).Inc()
+	httpDuration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+// ObserveQuery starts timing a repository method, the returned func records
+// its duration:
+//
+//	defer metrics.ObserveQuery("todo_list", "GetAllLists")()
+func ObserveQuery(repository, method string) func() {
+	start := time.Now()
+
+	return func() {
+		queryDuration.WithLabelValues(repository, method).Observe(time.Since(start).Seconds())
+	}
+}
+
+func AuthEvent(event string) {
+	authEvents.WithLabelValues(event).Inc()
+}
@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+)
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// RateLimitStore keeps token buckets in memory, for single instance
+// deployments.
+type RateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func NewRateLimitStore() *RateLimitStore {
+	return &RateLimitStore{buckets: make(map[string]*bucket)}
+}
+
+// Take takes a token from the key's bucket, returning whether there was one
+// and the tokens left.
+func (s *RateLimitStore) Take(ctx context.Context, key string, limit domain.RateLimit) (bool, float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updatedAt: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = limit.Refill(b.tokens, now.Sub(b.updatedAt))
+	b.updatedAt = now
+
+	if b.tokens < 1 {
+		return false, b.tokens, nil
+	}
+
+	b.tokens--
+	return true, b.tokens, nil
+}
+
+// DeleteIdle deletes the buckets unused for idle, which are full again.
+func (s *RateLimitStore) DeleteIdle(ctx context.Context, idle time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for key, b := range s.buckets {
+		if time.Since(b.updatedAt) > idle {
+			delete(s.buckets, key)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
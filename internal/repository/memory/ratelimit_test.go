@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+)
+
+func TestRateLimitStoreTake(t *testing.T) {
+	ctx := context.Background()
+	// Refills one token an hour, so none come back during the test.
+	limit := domain.RateLimit{Limit: 1, Period: time.Hour, Burst: 3}
+
+	s := NewRateLimitStore()
+
+	for want := 2; want >= 0; want-- {
+		allowed, tokens, err := s.Take(ctx, "api:user:1", limit)
+		if err != nil || !allowed || int(tokens) != want {
+			t.Fatalf("got %v, %v tokens, %v, want allowed with %d tokens", allowed, tokens, err, want)
+		}
+	}
+
+	if allowed, tokens, _ := s.Take(ctx, "api:user:1", limit); allowed || tokens >= 1 {
+		t.Errorf("got %v with %v tokens, want the empty bucket to refuse", allowed, tokens)
+	}
+
+	if allowed, _, _ := s.Take(ctx, "api:user:2", limit); !allowed {
+		t.Error("another key's bucket was emptied too")
+	}
+}
+
+func TestRateLimitStoreDeleteIdle(t *testing.T) {
+	ctx := context.Background()
+	limit := domain.RateLimit{Limit: 1, Period: time.Hour, Burst: 3}
+
+	s := NewRateLimitStore()
+	s.Take(ctx, "idle", limit)
+	s.Take(ctx, "active", limit)
+	s.buckets["idle"].updatedAt = time.Now().Add(-2 * time.Hour)
+
+	deleted, err := s.DeleteIdle(ctx, time.Hour)
+	if err != nil || deleted != 1 {
+		t.Fatalf("deleted %d, %v, want 1", deleted, err)
+	}
+
+	if _, ok := s.buckets["active"]; !ok {
+		t.Error("the active bucket was deleted")
+	}
+}
@@ -0,0 +1,128 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/internal/metrics"
+)
+
+type AttachmentRepo struct {
+	db *sql.DB
+}
+
+func NewAttachmentRepo(db *sql.DB) *AttachmentRepo {
+	return &AttachmentRepo{db: db}
+}
+
+func (r *AttachmentRepo) CreateAttachment(ctx context.Context, attachment domain.Attachment) (int, error) {
+	defer metrics.ObserveQuery("attachment", "CreateAttachment")()
+
+	var id int
+
+	row := r.db.QueryRowContext(ctx, `INSERT INTO attachments (item_id, user_id, file_name, content_type, size, storage_key)
+	VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		attachment.ItemId, attachment.UserId, attachment.FileName, attachment.ContentType,
+		attachment.Size, attachment.StorageKey)
+	if err := row.Scan(&id); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (r *AttachmentRepo) GetAttachments(ctx context.Context, userId, itemId int) ([]domain.Attachment, error) {
+	defer metrics.ObserveQuery("attachment", "GetAttachments")()
+
+	var attachments []domain.Attachment
+
+	rows, err := r.db.QueryContext(ctx, `SELECT a.id, a.item_id, a.user_id, a.file_name, a.content_type, a.size,
+	a.storage_key, a.created_at FROM attachments a
+	JOIN todo_items ti ON a.item_id = ti.id
+	JOIN lists_items li ON a.item_id = li.item_id
+	JOIN users_lists ul ON li.list_id = ul.list_id
+	JOIN todo_lists tl ON li.list_id = tl.id
+	WHERE ul.user_id = $1 AND a.item_id = $2
+	AND ti.deleted_at IS NULL AND tl.deleted_at IS NULL
+	ORDER BY a.created_at`, userId, itemId)
+	if err != nil {
+		return attachments, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a domain.Attachment
+
+		if err := rows.Scan(&a.Id, &a.ItemId, &a.UserId, &a.FileName, &a.ContentType, &a.Size,
+			&a.StorageKey, &a.CreatedAt); err != nil {
+			return attachments, err
+		}
+
+		attachments = append(attachments, a)
+	}
+
+	return attachments, rows.Err()
+}
+
+func (r *AttachmentRepo) GetAttachmentById(ctx context.Context, attachmentId int) (domain.Attachment, error) {
+	defer metrics.ObserveQuery("attachment", "GetAttachmentById")()
+
+	var a domain.Attachment
+
+	// Attachments of items in the trash can't be downloaded.
+	row := r.db.QueryRowContext(ctx, `SELECT a.id, a.item_id, a.user_id, a.file_name, a.content_type, a.size,
+	a.storage_key, a.created_at FROM attachments a
+	JOIN todo_items ti ON a.item_id = ti.id
+	JOIN lists_items li ON a.item_id = li.item_id
+	JOIN todo_lists tl ON li.list_id = tl.id
+	WHERE a.id = $1 AND ti.deleted_at IS NULL AND tl.deleted_at IS NULL`, attachmentId)
+	if err := row.Scan(&a.Id, &a.ItemId, &a.UserId, &a.FileName, &a.ContentType, &a.Size,
+		&a.StorageKey, &a.CreatedAt); err != nil {
+		return a, err
+	}
+
+	return a, nil
+}
+
+func (r *AttachmentRepo) DeleteAttachment(ctx context.Context, userId, attachmentId int) error {
+	defer metrics.ObserveQuery("attachment", "DeleteAttachment")()
+
+	_, err := r.db.ExecContext(ctx, `DELETE FROM attachments a USING todo_items ti, lists_items li, users_lists ul, todo_lists tl
+	WHERE a.item_id = ti.id AND a.item_id = li.item_id AND li.list_id = ul.list_id AND li.list_id = tl.id
+	AND ul.user_id = $1 AND a.id = $2 AND ti.deleted_at IS NULL AND tl.deleted_at IS NULL`, userId, attachmentId)
+
+	return err
+}
+
+func (r *AttachmentRepo) GetOrphanedBlobs(ctx context.Context, limit int) ([]string, error) {
+	defer metrics.ObserveQuery("attachment", "GetOrphanedBlobs")()
+
+	var keys []string
+
+	rows, err := r.db.QueryContext(ctx, "SELECT storage_key FROM orphaned_blobs ORDER BY created_at LIMIT $1", limit)
+	if err != nil {
+		return keys, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+
+		if err := rows.Scan(&key); err != nil {
+			return keys, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (r *AttachmentRepo) DeleteOrphanedBlob(ctx context.Context, key string) error {
+	defer metrics.ObserveQuery("attachment", "DeleteOrphanedBlob")()
+
+	_, err := r.db.ExecContext(ctx, "DELETE FROM orphaned_blobs WHERE storage_key = $1", key)
+
+	return err
+}
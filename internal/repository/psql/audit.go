@@ -0,0 +1,365 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/internal/metrics"
+)
+
+type AuditRepo struct {
+	db *sql.DB
+}
+
+func NewAuditRepo(db *sql.DB) *AuditRepo {
+	return &AuditRepo{db: db}
+}
+
+func (r *AuditRepo) GetListHistory(ctx context.Context, userId, listId int,
+	page domain.Pagination) ([]domain.AuditEvent, error) {
+	defer metrics.ObserveQuery("audit", "GetListHistory")()
+
+	rows, err := r.db.QueryContext(ctx, `SELECT ae.id, ae.entity_type, ae.entity_id, ae.actor_id, ae.action,
+	ae.before, ae.after, ae.request_id, ae.created_at FROM audit_events ae
+	JOIN users_lists ul ON ae.entity_id = ul.list_id
+	WHERE ae.entity_type = $1 AND ul.user_id = $2 AND ae.entity_id = $3
+	ORDER BY ae.id DESC LIMIT $4 OFFSET $5`,
+		domain.EntityTypeList, userId, listId, page.Limit, page.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanAuditEvents(rows)
+}
+
+func (r *AuditRepo) GetItemHistory(ctx context.Context, userId, itemId int,
+	page domain.Pagination) ([]domain.AuditEvent, error) {
+	defer metrics.ObserveQuery("audit", "GetItemHistory")()
+
+	rows, err := r.db.QueryContext(ctx, `SELECT ae.id, ae.entity_type, ae.entity_id, ae.actor_id, ae.action,
+	ae.before, ae.after, ae.request_id, ae.created_at FROM audit_events ae
+	JOIN lists_items li ON ae.entity_id = li.item_id
+	JOIN users_lists ul ON li.list_id = ul.list_id
+	WHERE ae.entity_type = $1 AND ul.user_id = $2 AND ae.entity_id = $3
+	ORDER BY ae.id DESC LIMIT $4 OFFSET $5`,
+		domain.EntityTypeItem, userId, itemId, page.Limit, page.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanAuditEvents(rows)
+}
+
+func (r *AuditRepo) GetEvents(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEvent, error) {
+	defer metrics.ObserveQuery("audit", "GetEvents")()
+
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+	argId := 1
+
+	if filter.ActorId != nil {
+		conditions = append(conditions, fmt.Sprintf("actor_id=$%d", argId))
+		args = append(args, *filter.ActorId)
+		argId++
+	}
+
+	if filter.EntityType != "" {
+		conditions = append(conditions, fmt.Sprintf("entity_type=$%d", argId))
+		args = append(args, filter.EntityType)
+		argId++
+	}
+
+	if filter.EntityId != nil {
+		conditions = append(conditions, fmt.Sprintf("entity_id=$%d", argId))
+		args = append(args, *filter.EntityId)
+		argId++
+	}
+
+	if filter.Action != "" {
+		conditions = append(conditions, fmt.Sprintf("action=$%d", argId))
+		args = append(args, filter.Action)
+		argId++
+	}
+
+	if filter.From != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at>=$%d", argId))
+		args = append(args, *filter.From)
+		argId++
+	}
+
+	if filter.To != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at<$%d", argId))
+		args = append(args, *filter.To)
+		argId++
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+
+	query := fmt.Sprintf(`SELECT id, entity_type, entity_id, actor_id, action, before, after, request_id, created_at
+	FROM audit_events %s ORDER BY id DESC LIMIT $%d OFFSET $%d`, where, argId, argId+1)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanAuditEvents(rows)
+}
+
+func scanAuditEvents(rows *sql.Rows) ([]domain.AuditEvent, error) {
+	var events []domain.AuditEvent
+
+	for rows.Next() {
+		event, err := scanAuditEvent(rows)
+		if err != nil {
+			return events, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAuditEvent(row scanner) (domain.AuditEvent, error) {
+	var event domain.AuditEvent
+	var before, after []byte
+	var requestId sql.NullString
+
+	if err := row.Scan(&event.Id, &event.EntityType, &event.EntityId, &event.ActorId, &event.Action,
+		&before, &after, &requestId, &event.CreatedAt); err != nil {
+		return event, err
+	}
+
+	event.RequestId = requestId.String
+
+	if before != nil {
+		if err := json.Unmarshal(before, &event.Before); err != nil {
+			return event, err
+		}
+	}
+
+	if after != nil {
+		if err := json.Unmarshal(after, &event.After); err != nil {
+			return event, err
+		}
+	}
+
+	return event, nil
+}
+
+// recordEvent appends an audit event as part of the mutation's transaction,
+// with the id of the request that made it.
+func recordEvent(ctx context.Context, tx *sql.Tx, event domain.AuditEvent) error {
+	if event.RequestId == "" {
+		event.RequestId = domain.RequestIdFromContext(ctx)
+	}
+
+	before, err := marshalFields(event.Before)
+	if err != nil {
+		return err
+	}
+
+	after, err := marshalFields(event.After)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO audit_events (entity_type, entity_id, actor_id, action, before, after, request_id)
+	VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))`,
+		event.EntityType, event.EntityId, event.ActorId, event.Action, before, after, event.RequestId)
+
+	return err
+}
+
+func diffFields(before, after domain.AuditFields) (domain.AuditFields, domain.AuditFields) {
+	changedBefore := make(domain.AuditFields)
+	changedAfter := make(domain.AuditFields)
+
+	for field, value := range after {
+		if !reflect.DeepEqual(before[field], value) {
+			changedBefore[field] = before[field]
+			changedAfter[field] = value
+		}
+	}
+
+	return changedBefore, changedAfter
+}
+
+func marshalFields(fields domain.AuditFields) ([]byte, error) {
+	if fields == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(fields)
+}
+
+// listSnapshot locks a list the user has access to and returns its audited fields.
+func listSnapshot(ctx context.Context, tx *sql.Tx, userId, listId int, withDeleted bool) (domain.AuditFields, error) {
+	var title string
+	var description *string
+	var isTemplate, archived, deleted bool
+
+	row := tx.QueryRowContext(ctx, `SELECT tl.title, tl.description, tl.is_template,
+	tl.archived_at IS NOT NULL, tl.deleted_at IS NOT NULL FROM todo_lists tl
+	JOIN users_lists ul ON tl.id = ul.list_id
+	WHERE ul.user_id = $1 AND tl.id = $2 AND ($3 OR tl.deleted_at IS NULL)
+	FOR UPDATE OF tl`, userId, listId, withDeleted)
+	if err := row.Scan(&title, &description, &isTemplate, &archived, &deleted); err != nil {
+		return nil, err
+	}
+
+	return domain.AuditFields{
+		"title":       title,
+		"description": description,
+		"is_template": isTemplate,
+		"archived":    archived,
+		"deleted":     deleted,
+	}, nil
+}
+
+// itemSnapshot locks an item the user has access to and returns its audited fields.
+func itemSnapshot(ctx context.Context, tx *sql.Tx, userId, itemId int, withDeleted bool) (domain.AuditFields, error) {
+	var title string
+	var description *string
+	var done, deleted bool
+	var listId int
+
+	row := tx.QueryRowContext(ctx, `SELECT ti.title, ti.description, ti.done, li.list_id,
+	ti.deleted_at IS NOT NULL FROM todo_items ti
+	JOIN lists_items li ON ti.id = li.item_id
+	JOIN users_lists ul ON li.list_id = ul.list_id
+	JOIN todo_lists tl ON li.list_id = tl.id
+	WHERE ul.user_id = $1 AND ti.id = $2 AND tl.deleted_at IS NULL
+	AND ($3 OR ti.deleted_at IS NULL)
+	FOR UPDATE OF ti, li`, userId, itemId, withDeleted)
+	if err := row.Scan(&title, &description, &done, &listId, &deleted); err != nil {
+		return nil, err
+	}
+
+	return domain.AuditFields{
+		"title":       title,
+		"description": description,
+		"done":        done,
+		"list_id":     listId,
+		"deleted":     deleted,
+	}, nil
+}
+
+// auditedChange applies change within tx and records how it affected the
+// entity's fields, as captured by snapshot before and after the change.
+// Entities whose fields changed get their version bumped.
+func auditedChange(ctx context.Context, tx *sql.Tx, userId int, entityType string, entityId int, action string,
+	snapshot func(tx *sql.Tx) (domain.AuditFields, error), change func(tx *sql.Tx) error) error {
+	before, err := snapshot(tx)
+	if err != nil {
+		return err
+	}
+
+	if err := change(tx); err != nil {
+		return err
+	}
+
+	after, err := snapshot(tx)
+	if err != nil {
+		return err
+	}
+
+	changedBefore, changedAfter := diffFields(before, after)
+	if len(changedAfter) == 0 {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET version = version + 1 WHERE id = $1", entityTable(entityType)),
+		entityId)
+	if err != nil {
+		return err
+	}
+
+	return recordEvent(ctx, tx, domain.AuditEvent{
+		EntityType: entityType,
+		EntityId:   entityId,
+		ActorId:    &userId,
+		Action:     action,
+		Before:     changedBefore,
+		After:      changedAfter,
+	})
+}
+
+// checkVersion fails with domain.ErrVersionMismatch unless the entity is
+// at the expected version. A nil version skips the check.
+func checkVersion(ctx context.Context, tx *sql.Tx, entityType string, entityId int, expected domain.VersionMatch) error {
+	if expected == nil {
+		return nil
+	}
+
+	var version int
+	row := tx.QueryRowContext(ctx, fmt.Sprintf("SELECT version FROM %s WHERE id = $1", entityTable(entityType)), entityId)
+	if err := row.Scan(&version); err != nil {
+		return err
+	}
+
+	if !expected.Matches(version) {
+		return domain.ErrVersionMismatch
+	}
+
+	return nil
+}
+
+func entityTable(entityType string) string {
+	if entityType == domain.EntityTypeItem {
+		return "todo_items"
+	}
+
+	return "todo_lists"
+}
+
+// includesDeleted reports whether an action may see entities in the trash.
+func includesDeleted(action string) bool {
+	return action == domain.AuditActionDelete || action == domain.AuditActionRestore
+}
+
+func recordCreated(ctx context.Context, tx *sql.Tx, userId int, entityType string, entityId int,
+	fields domain.AuditFields) error {
+	return recordEvent(ctx, tx, domain.AuditEvent{
+		EntityType: entityType,
+		EntityId:   entityId,
+		ActorId:    &userId,
+		Action:     domain.AuditActionCreate,
+		After:      fields,
+	})
+}
+
+func createdListFields(list domain.TodoList) domain.AuditFields {
+	return domain.AuditFields{
+		"title":       list.Title,
+		"description": list.Description,
+		"is_template": list.IsTemplate,
+		"archived":    false,
+		"deleted":     false,
+	}
+}
+
+func createdItemFields(listId int, item domain.TodoItem) domain.AuditFields {
+	return domain.AuditFields{
+		"title":       item.Title,
+		"description": item.Description,
+		"done":        item.Done,
+		"list_id":     listId,
+		"deleted":     false,
+	}
+}
@@ -1,9 +1,11 @@
 package psql
 
 import (
+	"context"
 	"database/sql"
 
 	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/internal/metrics"
 )
 
 type AuthRepo struct {
@@ -14,10 +16,12 @@ func NewAuthRepo(db *sql.DB) *AuthRepo {
 	return &AuthRepo{db: db}
 }
 
-func (s *AuthRepo) CreateUser(user domain.User) (int, error) {
+func (s *AuthRepo) CreateUser(ctx context.Context, user domain.User) (int, error) {
+	defer metrics.ObserveQuery("auth", "CreateUser")()
+
 	var id int
 
-	row := s.db.QueryRow("INSERT INTO users (name, email, password_hash, registered) values ($1, $2, $3, $4) RETURNING id",
+	row := s.db.QueryRowContext(ctx, "INSERT INTO users (name, email, password_hash, registered) values ($1, $2, $3, $4) RETURNING id",
 		user.Name, user.Email, user.PasswordHash, user.Registered)
 	if err := row.Scan(&id); err != nil {
 		return 0, err
@@ -26,10 +30,12 @@ func (s *AuthRepo) CreateUser(user domain.User) (int, error) {
 	return id, nil
 }
 
-func (s *AuthRepo) GetUserId(email, password string) (int, error) {
+func (s *AuthRepo) GetUserId(ctx context.Context, email, password string) (int, error) {
+	defer metrics.ObserveQuery("auth", "GetUserId")()
+
 	var userId int
 
-	row := s.db.QueryRow("SELECT id FROM users WHERE email=$1 AND password_hash=$2",
+	row := s.db.QueryRowContext(ctx, "SELECT id FROM users WHERE email=$1 AND password_hash=$2",
 		email, password)
 	if err := row.Scan(&userId); err != nil {
 		return 0, err
@@ -37,3 +43,29 @@ func (s *AuthRepo) GetUserId(email, password string) (int, error) {
 
 	return userId, nil
 }
+
+func (s *AuthRepo) GetUserById(ctx context.Context, userId int) (domain.User, error) {
+	defer metrics.ObserveQuery("auth", "GetUserById")()
+
+	var user domain.User
+
+	row := s.db.QueryRowContext(ctx, "SELECT id, name, email, registered FROM users WHERE id=$1", userId)
+	if err := row.Scan(&user.Id, &user.Name, &user.Email, &user.Registered); err != nil {
+		return user, err
+	}
+
+	return user, nil
+}
+
+func (s *AuthRepo) IsAdmin(ctx context.Context, userId int) (bool, error) {
+	defer metrics.ObserveQuery("auth", "IsAdmin")()
+
+	var isAdmin bool
+
+	row := s.db.QueryRowContext(ctx, "SELECT is_admin FROM users WHERE id=$1", userId)
+	if err := row.Scan(&isAdmin); err != nil {
+		return false, err
+	}
+
+	return isAdmin, nil
+}
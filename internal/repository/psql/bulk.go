@@ -0,0 +1,217 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/internal/metrics"
+	"github.com/lib/pq"
+)
+
+// ApplyBulk runs the operations in one transaction. Access is checked once
+// per affected list before anything runs. In partial mode every operation
+// gets its own savepoint, so a failure only undoes that operation.
+func (r *TodoItemRepo) ApplyBulk(ctx context.Context, userId int, input domain.BulkInput) ([]domain.BulkResult, error) {
+	defer metrics.ObserveQuery("todo_item", "ApplyBulk")()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	itemLists, err := bulkItemLists(ctx, tx, input.ItemIds())
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	listIds := input.ListIds()
+	for _, listId := range itemLists {
+		listIds = append(listIds, listId)
+	}
+
+	allowed, err := accessibleLists(ctx, tx, userId, listIds)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	partial := input.Mode == domain.BulkModePartial
+
+	results, err := runBulk(input, func(op domain.BulkOperation) (error, error) {
+		if partial {
+			if _, err := tx.ExecContext(ctx, "SAVEPOINT bulk_op"); err != nil {
+				return nil, err
+			}
+		}
+
+		opErr := applyBulkOp(ctx, tx, userId, op, itemLists, allowed)
+
+		if partial {
+			release := "RELEASE SAVEPOINT bulk_op"
+			if opErr != nil {
+				release = "ROLLBACK TO SAVEPOINT bulk_op"
+			}
+
+			if _, err := tx.ExecContext(ctx, release); err != nil {
+				return nil, err
+			}
+		}
+
+		return opErr, nil
+	})
+	if err != nil {
+		tx.Rollback()
+		return results, err
+	}
+
+	return results, tx.Commit()
+}
+
+// runBulk runs the operations in order and reports the result of each. run
+// returns the operation's own error, which fails just that operation in
+// partial mode, and the errors that abort the whole request. In atomic mode
+// the first failed operation skips the rest, and the ones before it are
+// reported rolled back along with domain.ErrBulkFailed.
+func runBulk(input domain.BulkInput,
+	run func(op domain.BulkOperation) (opErr error, err error)) ([]domain.BulkResult, error) {
+	partial := input.Mode == domain.BulkModePartial
+	results := make([]domain.BulkResult, len(input.Operations))
+	failedIdx := -1
+
+	for idx, op := range input.Operations {
+		results[idx] = domain.BulkResult{Op: op.Op, ItemId: op.ItemId, Status: domain.BulkStatusOk}
+
+		if failedIdx >= 0 {
+			results[idx].Status = domain.BulkStatusSkipped
+			continue
+		}
+
+		opErr, err := run(op)
+		if err != nil {
+			return nil, err
+		}
+
+		if opErr != nil {
+			results[idx].Status = domain.BulkStatusFailed
+			results[idx].Error = opErr.Error()
+
+			if !partial {
+				failedIdx = idx
+			}
+		}
+	}
+
+	if failedIdx >= 0 {
+		for idx := range results[:failedIdx] {
+			results[idx].Status = domain.BulkStatusRolledBack
+		}
+
+		return results, domain.ErrBulkFailed
+	}
+
+	return results, nil
+}
+
+func applyBulkOp(ctx context.Context, tx *sql.Tx, userId int, op domain.BulkOperation,
+	itemLists map[int]int, allowed map[int]bool) error {
+	if listId, ok := itemLists[op.ItemId]; !ok || !allowed[listId] {
+		return fmt.Errorf("item %d not found", op.ItemId)
+	}
+
+	var action string
+	var apply func(tx *sql.Tx) error
+
+	switch op.Op {
+	case domain.BulkOpUpdate:
+		query, args := itemUpdateQuery(op.ItemId, *op.Update)
+		action = domain.AuditActionUpdate
+		apply = func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, query, args...)
+			return err
+		}
+	case domain.BulkOpComplete:
+		action = domain.AuditActionUpdate
+		apply = func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "UPDATE todo_items SET done = true WHERE id = $1", op.ItemId)
+			return err
+		}
+	case domain.BulkOpDelete:
+		action = domain.AuditActionDelete
+		apply = func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "UPDATE todo_items SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL", op.ItemId)
+			return err
+		}
+	case domain.BulkOpMove:
+		if !allowed[op.ListId] {
+			return fmt.Errorf("list %d not found", op.ListId)
+		}
+
+		action = domain.AuditActionMove
+		apply = func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "UPDATE lists_items SET list_id = $1 WHERE item_id = $2", op.ListId, op.ItemId)
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown op %q", op.Op)
+	}
+
+	err := auditedItemChange(ctx, tx, userId, op.ItemId, action, nil, apply)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("item %d not found", op.ItemId)
+	}
+
+	return err
+}
+
+// bulkItemLists returns the list of every live item among itemIds.
+func bulkItemLists(ctx context.Context, tx *sql.Tx, itemIds []int) (map[int]int, error) {
+	itemLists := make(map[int]int)
+
+	rows, err := tx.QueryContext(ctx, `SELECT li.item_id, li.list_id FROM lists_items li
+	JOIN todo_items ti ON li.item_id = ti.id
+	JOIN todo_lists tl ON li.list_id = tl.id
+	WHERE li.item_id = ANY($1) AND ti.deleted_at IS NULL AND tl.deleted_at IS NULL`, pq.Array(itemIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var itemId, listId int
+		if err := rows.Scan(&itemId, &listId); err != nil {
+			return nil, err
+		}
+
+		itemLists[itemId] = listId
+	}
+
+	return itemLists, rows.Err()
+}
+
+// accessibleLists returns which of the live lists among listIds the user can access.
+func accessibleLists(ctx context.Context, tx *sql.Tx, userId int, listIds []int) (map[int]bool, error) {
+	allowed := make(map[int]bool)
+
+	rows, err := tx.QueryContext(ctx, `SELECT ul.list_id FROM users_lists ul
+	JOIN todo_lists tl ON ul.list_id = tl.id
+	WHERE ul.user_id = $1 AND ul.list_id = ANY($2) AND tl.deleted_at IS NULL`, userId, pq.Array(listIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var listId int
+		if err := rows.Scan(&listId); err != nil {
+			return nil, err
+		}
+
+		allowed[listId] = true
+	}
+
+	return allowed, rows.Err()
+}
@@ -0,0 +1,145 @@
+package psql
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+)
+
+func TestRunBulk(t *testing.T) {
+	ops := []domain.BulkOperation{
+		{Op: domain.BulkOpComplete, ItemId: 1},
+		{Op: domain.BulkOpDelete, ItemId: 2},
+		{Op: domain.BulkOpMove, ItemId: 3, ListId: 9},
+	}
+
+	tests := []struct {
+		name      string
+		mode      string
+		failing   map[int]bool
+		want      []string
+		wantErr   error
+		wantCalls int
+	}{
+		{
+			name:      "atomic, all succeed",
+			mode:      domain.BulkModeAtomic,
+			want:      []string{domain.BulkStatusOk, domain.BulkStatusOk, domain.BulkStatusOk},
+			wantCalls: 3,
+		},
+		{
+			name:      "atomic, middle fails",
+			mode:      domain.BulkModeAtomic,
+			failing:   map[int]bool{2: true},
+			want:      []string{domain.BulkStatusRolledBack, domain.BulkStatusFailed, domain.BulkStatusSkipped},
+			wantErr:   domain.ErrBulkFailed,
+			wantCalls: 2,
+		},
+		{
+			name:      "atomic, first fails",
+			mode:      domain.BulkModeAtomic,
+			failing:   map[int]bool{1: true},
+			want:      []string{domain.BulkStatusFailed, domain.BulkStatusSkipped, domain.BulkStatusSkipped},
+			wantErr:   domain.ErrBulkFailed,
+			wantCalls: 1,
+		},
+		{
+			name:      "atomic, last fails",
+			mode:      domain.BulkModeAtomic,
+			failing:   map[int]bool{3: true},
+			want:      []string{domain.BulkStatusRolledBack, domain.BulkStatusRolledBack, domain.BulkStatusFailed},
+			wantErr:   domain.ErrBulkFailed,
+			wantCalls: 3,
+		},
+		{
+			name:      "partial, all succeed",
+			mode:      domain.BulkModePartial,
+			want:      []string{domain.BulkStatusOk, domain.BulkStatusOk, domain.BulkStatusOk},
+			wantCalls: 3,
+		},
+		{
+			name:      "partial, some fail",
+			mode:      domain.BulkModePartial,
+			failing:   map[int]bool{1: true, 3: true},
+			want:      []string{domain.BulkStatusFailed, domain.BulkStatusOk, domain.BulkStatusFailed},
+			wantCalls: 3,
+		},
+		{
+			name:      "partial, all fail",
+			mode:      domain.BulkModePartial,
+			failing:   map[int]bool{1: true, 2: true, 3: true},
+			want:      []string{domain.BulkStatusFailed, domain.BulkStatusFailed, domain.BulkStatusFailed},
+			wantCalls: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			results, err := runBulk(domain.BulkInput{Mode: tt.mode, Operations: ops},
+				func(op domain.BulkOperation) (error, error) {
+					calls++
+					if tt.failing[op.ItemId] {
+						return fmt.Errorf("item %d not found", op.ItemId), nil
+					}
+					return nil, nil
+				})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+
+			if calls != tt.wantCalls {
+				t.Errorf("ran %d operations, want %d", calls, tt.wantCalls)
+			}
+
+			if len(results) != len(ops) {
+				t.Fatalf("got %d results, want %d", len(results), len(ops))
+			}
+
+			for idx, result := range results {
+				if result.Op != ops[idx].Op || result.ItemId != ops[idx].ItemId {
+					t.Errorf("result %d is for %s %d, want %s %d", idx, result.Op, result.ItemId,
+						ops[idx].Op, ops[idx].ItemId)
+				}
+
+				if result.Status != tt.want[idx] {
+					t.Errorf("result %d: status %q, want %q", idx, result.Status, tt.want[idx])
+				}
+
+				if (result.Status == domain.BulkStatusFailed) != (result.Error != "") {
+					t.Errorf("result %d: status %q with error %q", idx, result.Status, result.Error)
+				}
+			}
+		})
+	}
+}
+
+func TestRunBulkAborts(t *testing.T) {
+	errConn := errors.New("connection reset")
+
+	for _, mode := range []string{domain.BulkModeAtomic, domain.BulkModePartial} {
+		t.Run(mode, func(t *testing.T) {
+			input := domain.BulkInput{Mode: mode, Operations: []domain.BulkOperation{
+				{Op: domain.BulkOpComplete, ItemId: 1},
+				{Op: domain.BulkOpComplete, ItemId: 2},
+			}}
+
+			calls := 0
+			results, err := runBulk(input, func(op domain.BulkOperation) (error, error) {
+				calls++
+				return nil, errConn
+			})
+
+			if !errors.Is(err, errConn) || results != nil {
+				t.Errorf("got %v, %v, want no results and %v", results, err, errConn)
+			}
+
+			if calls != 1 {
+				t.Errorf("ran %d operations after the request failed, want 1", calls)
+			}
+		})
+	}
+}
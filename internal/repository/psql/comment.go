@@ -0,0 +1,142 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/internal/metrics"
+	"github.com/lib/pq"
+)
+
+type CommentRepo struct {
+	db *sql.DB
+}
+
+func NewCommentRepo(db *sql.DB) *CommentRepo {
+	return &CommentRepo{db: db}
+}
+
+// commentAccessible is true when the author of comment c can still access
+// its item: they are a member of its list and neither is in the trash.
+const commentAccessible = `EXISTS (SELECT 1 FROM todo_items ti
+	JOIN lists_items li ON ti.id = li.item_id
+	JOIN users_lists ul ON li.list_id = ul.list_id
+	JOIN todo_lists tl ON li.list_id = tl.id
+	WHERE ti.id = c.item_id AND ul.user_id = c.user_id
+	AND ti.deleted_at IS NULL AND tl.deleted_at IS NULL)`
+
+func (r *CommentRepo) CreateComment(ctx context.Context, userId, itemId int, input domain.CommentInput) (int, error) {
+	defer metrics.ObserveQuery("comment", "CreateComment")()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var commentId int
+	row := tx.QueryRowContext(ctx, `INSERT INTO item_comments (item_id, user_id, body)
+	SELECT ti.id, ul.user_id, $3 FROM todo_items ti
+	JOIN lists_items li ON ti.id = li.item_id
+	JOIN users_lists ul ON li.list_id = ul.list_id
+	JOIN todo_lists tl ON li.list_id = tl.id
+	WHERE ul.user_id = $1 AND ti.id = $2
+	AND ti.deleted_at IS NULL AND tl.deleted_at IS NULL RETURNING id`, userId, itemId, input.Body)
+	if err := row.Scan(&commentId); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := notifyMentions(ctx, tx, userId, itemId, commentId, input.Mentions()); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return commentId, tx.Commit()
+}
+
+func (r *CommentRepo) GetComments(ctx context.Context, userId, itemId int,
+	page domain.Pagination) ([]domain.Comment, error) {
+	defer metrics.ObserveQuery("comment", "GetComments")()
+
+	var comments []domain.Comment
+
+	rows, err := r.db.QueryContext(ctx, `SELECT c.id, c.item_id, c.user_id, u.name, c.body, c.created_at, c.updated_at
+	FROM item_comments c
+	JOIN users u ON c.user_id = u.id
+	JOIN todo_items ti ON c.item_id = ti.id
+	JOIN lists_items li ON c.item_id = li.item_id
+	JOIN users_lists ul ON li.list_id = ul.list_id
+	JOIN todo_lists tl ON li.list_id = tl.id
+	WHERE ul.user_id = $1 AND c.item_id = $2
+	AND ti.deleted_at IS NULL AND tl.deleted_at IS NULL
+	ORDER BY c.created_at, c.id LIMIT $3 OFFSET $4`, userId, itemId, page.Limit, page.Offset)
+	if err != nil {
+		return comments, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var comment domain.Comment
+
+		if err := rows.Scan(&comment.Id, &comment.ItemId, &comment.UserId, &comment.Author,
+			&comment.Body, &comment.CreatedAt, &comment.UpdatedAt); err != nil {
+			return comments, err
+		}
+
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
+
+func (r *CommentRepo) UpdateComment(ctx context.Context, userId, commentId int, input domain.CommentInput) error {
+	defer metrics.ObserveQuery("comment", "UpdateComment")()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	var itemId int
+	row := tx.QueryRowContext(ctx, `UPDATE item_comments c SET body = $1, updated_at = now()
+	WHERE c.id = $2 AND c.user_id = $3 AND `+commentAccessible+` RETURNING c.item_id`, input.Body, commentId, userId)
+	if err := row.Scan(&itemId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := notifyMentions(ctx, tx, userId, itemId, commentId, input.Mentions()); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *CommentRepo) DeleteComment(ctx context.Context, userId, commentId int) error {
+	defer metrics.ObserveQuery("comment", "DeleteComment")()
+
+	_, err := r.db.ExecContext(ctx, `DELETE FROM item_comments c
+	WHERE c.id = $1 AND c.user_id = $2 AND `+commentAccessible, commentId, userId)
+
+	return err
+}
+
+// notifyMentions notifies members of the item's list mentioned by email,
+// skipping the author and anyone already notified about this comment.
+func notifyMentions(ctx context.Context, tx *sql.Tx, authorId, itemId, commentId int, emails []string) error {
+	if len(emails) == 0 {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, `INSERT INTO notifications (user_id, actor_id, type, item_id, comment_id)
+	SELECT DISTINCT u.id, $1::int, $2, $3::int, $4::int FROM users u
+	JOIN users_lists ul ON u.id = ul.user_id
+	JOIN lists_items li ON ul.list_id = li.list_id
+	WHERE li.item_id = $3 AND u.id <> $1 AND lower(u.email) = ANY($5)
+	AND NOT EXISTS (SELECT 1 FROM notifications n WHERE n.comment_id = $4 AND n.user_id = u.id)`,
+		authorId, domain.NotificationTypeMention, itemId, commentId, pq.Array(emails))
+
+	return err
+}
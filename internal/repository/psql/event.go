@@ -0,0 +1,192 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/internal/metrics"
+	"github.com/lib/pq"
+)
+
+// changeEventsChannel is notified with the id of every new audit event.
+const changeEventsChannel = "audit_events"
+
+// changeEventColumns selects a change event from audit_events ae. An item's
+// lists are the ones it moved between plus the list it is in now.
+const changeEventColumns = `ae.id, ae.entity_type, ae.entity_id, ae.actor_id, ae.action, ae.after, ae.created_at,
+	ARRAY(SELECT DISTINCT l FROM unnest(ARRAY[
+		CASE WHEN ae.entity_type = 'list' THEN ae.entity_id END,
+		(ae.before->>'list_id')::int,
+		(ae.after->>'list_id')::int,
+		(SELECT li.list_id FROM lists_items li WHERE ae.entity_type = 'item' AND li.item_id = ae.entity_id)
+	]) l WHERE l IS NOT NULL) AS list_ids`
+
+type EventRepo struct {
+	db      *sql.DB
+	connStr string
+}
+
+func NewEventRepo(db *sql.DB, connStr string) *EventRepo {
+	return &EventRepo{db: db, connStr: connStr}
+}
+
+// Listen calls notify with the id of every audit event committed by any app
+// instance until ctx is cancelled. After the connection is re-established
+// notify is called with 0, as events may have been missed meanwhile.
+func (r *EventRepo) Listen(ctx context.Context, notify func(eventId int)) error {
+	listener := pq.NewListener(r.connStr, time.Second, time.Minute, nil)
+	defer listener.Close()
+
+	if err := listener.Listen(changeEventsChannel); err != nil {
+		return err
+	}
+
+	ping := time.NewTicker(time.Minute)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ping.C:
+			go listener.Ping()
+		case n := <-listener.Notify:
+			if n == nil {
+				notify(0)
+				continue
+			}
+
+			if eventId, err := strconv.Atoi(n.Extra); err == nil {
+				notify(eventId)
+			}
+		}
+	}
+}
+
+func (r *EventRepo) GetChangeEvent(ctx context.Context, eventId int) (domain.ChangeEvent, error) {
+	defer metrics.ObserveQuery("event", "GetChangeEvent")()
+
+	row := r.db.QueryRowContext(ctx, `SELECT `+changeEventColumns+` FROM audit_events ae WHERE ae.id = $1`, eventId)
+
+	event, err := scanChangeEvent(row)
+	if err != nil {
+		return event, err
+	}
+
+	event.UserIds, err = r.listUsers(ctx, event.ListIds)
+
+	return event, err
+}
+
+// GetChangeEventsAfter returns the events after eventId, oldest first.
+func (r *EventRepo) GetChangeEventsAfter(ctx context.Context, eventId, limit int) ([]domain.ChangeEvent, error) {
+	defer metrics.ObserveQuery("event", "GetChangeEventsAfter")()
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+changeEventColumns+` FROM audit_events ae
+	WHERE ae.id > $1 ORDER BY ae.id LIMIT $2`, eventId, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := scanChangeEvents(rows)
+	if err != nil {
+		return events, err
+	}
+
+	for i := range events {
+		if events[i].UserIds, err = r.listUsers(ctx, events[i].ListIds); err != nil {
+			return events, err
+		}
+	}
+
+	return events, nil
+}
+
+// GetUserChangeEventsAfter returns the events after eventId on lists the
+// user can access, oldest first.
+func (r *EventRepo) GetUserChangeEventsAfter(ctx context.Context, userId, eventId,
+	limit int) ([]domain.ChangeEvent, error) {
+	defer metrics.ObserveQuery("event", "GetUserChangeEventsAfter")()
+
+	rows, err := r.db.QueryContext(ctx, `SELECT * FROM (SELECT `+changeEventColumns+` FROM audit_events ae
+	WHERE ae.id > $2) e
+	WHERE e.list_ids && ARRAY(SELECT ul.list_id FROM users_lists ul WHERE ul.user_id = $1)
+	ORDER BY e.id LIMIT $3`, userId, eventId, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := scanChangeEvents(rows)
+	if err != nil {
+		return events, err
+	}
+
+	for i := range events {
+		events[i].UserIds = []int{userId}
+	}
+
+	return events, nil
+}
+
+func (r *EventRepo) listUsers(ctx context.Context, listIds []int) ([]int, error) {
+	var userIds []int
+
+	rows, err := r.db.QueryContext(ctx, "SELECT DISTINCT user_id FROM users_lists WHERE list_id = ANY($1)", pq.Array(listIds))
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var userId int
+		if err := rows.Scan(&userId); err != nil {
+			return userIds, err
+		}
+
+		userIds = append(userIds, userId)
+	}
+
+	return userIds, rows.Err()
+}
+
+func scanChangeEvents(rows *sql.Rows) ([]domain.ChangeEvent, error) {
+	var events []domain.ChangeEvent
+
+	for rows.Next() {
+		event, err := scanChangeEvent(rows)
+		if err != nil {
+			return events, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func scanChangeEvent(row scanner) (domain.ChangeEvent, error) {
+	var event domain.ChangeEvent
+	var after []byte
+	var listIds []int64
+
+	if err := row.Scan(&event.Id, &event.EntityType, &event.EntityId, &event.ActorId, &event.Action,
+		&after, &event.CreatedAt, pq.Array(&listIds)); err != nil {
+		return event, err
+	}
+
+	if after != nil {
+		if err := json.Unmarshal(after, &event.Fields); err != nil {
+			return event, err
+		}
+	}
+
+	event.ListIds = make([]int, len(listIds))
+	for i, listId := range listIds {
+		event.ListIds[i] = int(listId)
+	}
+
+	return event, nil
+}
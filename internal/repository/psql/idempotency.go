@@ -0,0 +1,98 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/internal/metrics"
+)
+
+type IdempotencyRepo struct {
+	db *sql.DB
+}
+
+func NewIdempotencyRepo(db *sql.DB) *IdempotencyRepo {
+	return &IdempotencyRepo{db: db}
+}
+
+// Reserve claims the key for a new request for the lease and returns nil,
+// or returns the record of the unexpired request that already claimed it.
+func (r *IdempotencyRepo) Reserve(ctx context.Context, userId int, key, fingerprint string,
+	lease time.Duration) (*domain.IdempotencyRecord, error) {
+	defer metrics.ObserveQuery("idempotency", "Reserve")()
+
+	var reserved bool
+
+	row := r.db.QueryRowContext(ctx, `INSERT INTO idempotency_keys (user_id, key, fingerprint, expires_at)
+	VALUES ($1, $2, $3, now() + make_interval(secs => $4))
+	ON CONFLICT (user_id, key) DO UPDATE
+	SET fingerprint = EXCLUDED.fingerprint, status_code = NULL, content_type = NULL, body = NULL,
+	created_at = now(), expires_at = EXCLUDED.expires_at
+	WHERE idempotency_keys.expires_at < now()
+	RETURNING true`, userId, key, fingerprint, lease.Seconds())
+	err := row.Scan(&reserved)
+	if err == nil {
+		return nil, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	var record domain.IdempotencyRecord
+	var statusCode sql.NullInt64
+	var contentType sql.NullString
+	var body []byte
+
+	row = r.db.QueryRowContext(ctx, `SELECT fingerprint, status_code, content_type, body FROM idempotency_keys
+	WHERE user_id = $1 AND key = $2`, userId, key)
+	if err := row.Scan(&record.Fingerprint, &statusCode, &contentType, &body); err != nil {
+		return nil, err
+	}
+
+	if statusCode.Valid {
+		record.Response = &domain.StoredResponse{
+			StatusCode:  int(statusCode.Int64),
+			ContentType: contentType.String,
+			Body:        body,
+		}
+	}
+
+	return &record, nil
+}
+
+// Complete stores the response and keeps it for ttl.
+func (r *IdempotencyRepo) Complete(ctx context.Context, userId int, key string, response domain.StoredResponse,
+	ttl time.Duration) error {
+	defer metrics.ObserveQuery("idempotency", "Complete")()
+
+	_, err := r.db.ExecContext(ctx, `UPDATE idempotency_keys SET status_code = $3, content_type = $4, body = $5,
+	expires_at = now() + make_interval(secs => $6)
+	WHERE user_id = $1 AND key = $2 AND status_code IS NULL`,
+		userId, key, response.StatusCode, response.ContentType, response.Body, ttl.Seconds())
+
+	return err
+}
+
+func (r *IdempotencyRepo) Release(ctx context.Context, userId int, key string) error {
+	defer metrics.ObserveQuery("idempotency", "Release")()
+
+	_, err := r.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2 AND status_code IS NULL",
+		userId, key)
+
+	return err
+}
+
+func (r *IdempotencyRepo) DeleteExpired(ctx context.Context) (int64, error) {
+	defer metrics.ObserveQuery("idempotency", "DeleteExpired")()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at < now()")
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
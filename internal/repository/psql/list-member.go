@@ -0,0 +1,222 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/internal/metrics"
+)
+
+// AddMember shares the list with the user registered with email. Unknown
+// emails and existing members are ignored, so callers can't tell which
+// emails are registered.
+func (r *TodoListRepo) AddMember(ctx context.Context, userId, listId int, email string) error {
+	defer metrics.ObserveQuery("todo_list", "AddMember")()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := memberRole(ctx, tx, userId, listId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var memberId int
+	row := tx.QueryRowContext(ctx, "SELECT id FROM users WHERE lower(email) = lower($1)", email)
+	if err := row.Scan(&memberId); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, `INSERT INTO users_lists (user_id, list_id, role) VALUES ($1, $2, $3)
+	ON CONFLICT (user_id, list_id) DO NOTHING`, memberId, listId, domain.ListRoleMember)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	added, err := res.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if added == 0 {
+		tx.Rollback()
+		return nil
+	}
+
+	// The list and its items are new to the member, so their next sync has
+	// to pick them up however old its token is.
+	if err := touchList(ctx, tx, listId); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = recordEvent(ctx, tx, domain.AuditEvent{
+		EntityType: domain.EntityTypeList,
+		EntityId:   listId,
+		ActorId:    &userId,
+		Action:     domain.AuditActionAddMember,
+		After:      domain.AuditFields{"user_id": memberId},
+	})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *TodoListRepo) GetMembers(ctx context.Context, userId, listId int) ([]domain.ListMember, error) {
+	defer metrics.ObserveQuery("todo_list", "GetMembers")()
+
+	var members []domain.ListMember
+
+	rows, err := r.db.QueryContext(ctx, `SELECT u.id, u.name, u.email, m.role FROM users u
+	JOIN users_lists m ON u.id = m.user_id
+	JOIN users_lists ul ON m.list_id = ul.list_id
+	JOIN todo_lists tl ON m.list_id = tl.id
+	WHERE ul.user_id = $1 AND m.list_id = $2 AND tl.deleted_at IS NULL
+	ORDER BY u.id`, userId, listId)
+	if err != nil {
+		return members, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var member domain.ListMember
+
+		if err := rows.Scan(&member.UserId, &member.Name, &member.Email, &member.Role); err != nil {
+			return members, err
+		}
+
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+// RemoveMember removes a member from the list. The owner can remove anyone
+// but themselves, other members only themselves.
+func (r *TodoListRepo) RemoveMember(ctx context.Context, userId, listId, memberId int) error {
+	defer metrics.ObserveQuery("todo_list", "RemoveMember")()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	role, err := memberRole(ctx, tx, userId, listId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var removedRole string
+	row := tx.QueryRowContext(ctx, "SELECT role FROM users_lists WHERE user_id = $1 AND list_id = $2 FOR UPDATE",
+		memberId, listId)
+	if err := row.Scan(&removedRole); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := checkRemoval(userId, role, memberId, removedRole); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM users_lists WHERE user_id = $1 AND list_id = $2", memberId, listId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// The member's clients have to drop the list and its items on their
+	// next sync.
+	_, err = tx.ExecContext(ctx, `INSERT INTO sync_tombstones (entity_type, entity_id, user_id)
+	SELECT $1, $2::int, $3::int
+	UNION ALL
+	SELECT $4, li.item_id, $3 FROM lists_items li WHERE li.list_id = $2`,
+		domain.EntityTypeList, listId, memberId, domain.EntityTypeItem)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = recordEvent(ctx, tx, domain.AuditEvent{
+		EntityType: domain.EntityTypeList,
+		EntityId:   listId,
+		ActorId:    &userId,
+		Action:     domain.AuditActionRemoveMember,
+		Before:     domain.AuditFields{"user_id": memberId},
+	})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// checkRemoval reports whether a user with role may remove the member
+// with removedRole from the list.
+func checkRemoval(userId int, role string, memberId int, removedRole string) error {
+	if removedRole == domain.ListRoleOwner {
+		return domain.ErrListOwner
+	}
+
+	if memberId != userId && role != domain.ListRoleOwner {
+		return domain.ErrNotListOwner
+	}
+
+	return nil
+}
+
+// memberRole returns the user's role in the list, or sql.ErrNoRows unless
+// the user is a member of the list and the list isn't deleted.
+func memberRole(ctx context.Context, tx *sql.Tx, userId, listId int) (string, error) {
+	var role string
+	row := tx.QueryRowContext(ctx, `SELECT ul.role FROM users_lists ul
+	JOIN todo_lists tl ON ul.list_id = tl.id
+	WHERE ul.user_id = $1 AND ul.list_id = $2 AND tl.deleted_at IS NULL`, userId, listId)
+
+	err := row.Scan(&role)
+
+	return role, err
+}
+
+// checkOwner fails with domain.ErrNotListOwner unless the user owns the
+// list, deleted or not.
+func checkOwner(ctx context.Context, tx *sql.Tx, userId, listId int) error {
+	var role string
+	row := tx.QueryRowContext(ctx, "SELECT role FROM users_lists WHERE user_id = $1 AND list_id = $2", userId, listId)
+	if err := row.Scan(&role); err != nil {
+		return err
+	}
+
+	if role != domain.ListRoleOwner {
+		return domain.ErrNotListOwner
+	}
+
+	return nil
+}
+
+// touchList bumps the sync position of the list and its items.
+func touchList(ctx context.Context, tx *sql.Tx, listId int) error {
+	if _, err := tx.ExecContext(ctx, "UPDATE todo_lists SET change_seq = 0 WHERE id = $1", listId); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, `UPDATE todo_items SET change_seq = 0
+	WHERE id IN (SELECT item_id FROM lists_items WHERE list_id = $1)`, listId)
+
+	return err
+}
@@ -0,0 +1,52 @@
+package psql
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+)
+
+func TestCheckRemoval(t *testing.T) {
+	const userId, otherId = 1, 2
+
+	tests := []struct {
+		name        string
+		role        string
+		memberId    int
+		removedRole string
+		wantErr     error
+	}{
+		{name: "owner removes a member", role: domain.ListRoleOwner, memberId: otherId, removedRole: domain.ListRoleMember},
+		{name: "member leaves", role: domain.ListRoleMember, memberId: userId, removedRole: domain.ListRoleMember},
+		{
+			name:        "member removes another member",
+			role:        domain.ListRoleMember,
+			memberId:    otherId,
+			removedRole: domain.ListRoleMember,
+			wantErr:     domain.ErrNotListOwner,
+		},
+		{
+			name:        "member removes the owner",
+			role:        domain.ListRoleMember,
+			memberId:    otherId,
+			removedRole: domain.ListRoleOwner,
+			wantErr:     domain.ErrListOwner,
+		},
+		{
+			name:        "owner leaves",
+			role:        domain.ListRoleOwner,
+			memberId:    userId,
+			removedRole: domain.ListRoleOwner,
+			wantErr:     domain.ErrListOwner,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkRemoval(userId, tt.role, tt.memberId, tt.removedRole); !errors.Is(err, tt.wantErr) {
+				t.Errorf("got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/internal/metrics"
+)
+
+type NotificationRepo struct {
+	db *sql.DB
+}
+
+func NewNotificationRepo(db *sql.DB) *NotificationRepo {
+	return &NotificationRepo{db: db}
+}
+
+func (r *NotificationRepo) GetNotifications(ctx context.Context, userId int,
+	page domain.Pagination) ([]domain.Notification, error) {
+	defer metrics.ObserveQuery("notification", "GetNotifications")()
+
+	var notifications []domain.Notification
+
+	rows, err := r.db.QueryContext(ctx, `SELECT id, type, actor_id, item_id, comment_id, read, created_at
+	FROM notifications WHERE user_id = $1
+	ORDER BY created_at DESC, id DESC LIMIT $2 OFFSET $3`, userId, page.Limit, page.Offset)
+	if err != nil {
+		return notifications, err
+	}
+
+	for rows.Next() {
+		var n domain.Notification
+
+		if err := rows.Scan(&n.Id, &n.Type, &n.ActorId, &n.ItemId, &n.CommentId, &n.Read, &n.CreatedAt); err != nil {
+			return notifications, err
+		}
+
+		notifications = append(notifications, n)
+	}
+
+	return notifications, nil
+}
+
+func (r *NotificationRepo) MarkRead(ctx context.Context, userId, notificationId int) error {
+	defer metrics.ObserveQuery("notification", "MarkRead")()
+
+	_, err := r.db.ExecContext(ctx, "UPDATE notifications SET read = true WHERE id = $1 AND user_id = $2",
+		notificationId, userId)
+
+	return err
+}
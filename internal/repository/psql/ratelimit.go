@@ -0,0 +1,57 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/internal/metrics"
+)
+
+// RateLimitRepo keeps token buckets in Postgres, shared by all instances.
+type RateLimitRepo struct {
+	db *sql.DB
+}
+
+func NewRateLimitRepo(db *sql.DB) *RateLimitRepo {
+	return &RateLimitRepo{db: db}
+}
+
+// Take takes a token from the key's bucket, returning whether there was one
+// and the tokens left. The bucket is refilled and taken from in one
+// statement, so concurrent requests can't take the same token.
+func (r *RateLimitRepo) Take(ctx context.Context, key string, limit domain.RateLimit) (bool, float64, error) {
+	defer metrics.ObserveQuery("rate_limit", "Take")()
+
+	var allowed bool
+	var tokens float64
+
+	refill := "LEAST($2::float8, rl.tokens + EXTRACT(EPOCH FROM now() - rl.updated_at)::float8 * $3::float8)"
+
+	row := r.db.QueryRowContext(ctx, fmt.Sprintf(`INSERT INTO rate_limits AS rl (key, tokens, allowed, updated_at)
+	VALUES ($1, $2::float8 - 1, true, now())
+	ON CONFLICT (key) DO UPDATE
+	SET tokens = %[1]s - CASE WHEN %[1]s >= 1 THEN 1 ELSE 0 END,
+	allowed = %[1]s >= 1, updated_at = now()
+	RETURNING allowed, tokens`, refill), key, limit.Burst, limit.Rate())
+	if err := row.Scan(&allowed, &tokens); err != nil {
+		return false, 0, err
+	}
+
+	return allowed, tokens, nil
+}
+
+// DeleteIdle deletes the buckets unused for idle, which are full again.
+func (r *RateLimitRepo) DeleteIdle(ctx context.Context, idle time.Duration) (int64, error) {
+	defer metrics.ObserveQuery("rate_limit", "DeleteIdle")()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM rate_limits WHERE updated_at < now() - make_interval(secs => $1)",
+		idle.Seconds())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,120 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"html"
+	"strings"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/internal/metrics"
+)
+
+// ts_headline marks the matches with control characters, which are
+// stripped from the text first, so that the snippet can be escaped before
+// the marks are turned into <mark> tags.
+const (
+	headlineStart   = "\x01"
+	headlineStop    = "\x02"
+	headlineOptions = "StartSel=" + headlineStart + ", StopSel=" + headlineStop
+)
+
+var headlineMarks = strings.NewReplacer(headlineStart, "<mark>", headlineStop, "</mark>")
+
+type SearchRepo struct {
+	db       *sql.DB
+	language string
+}
+
+func NewSearchRepo(db *sql.DB, language string) *SearchRepo {
+	return &SearchRepo{db: db, language: language}
+}
+
+// SyncLanguage stores the configured text search language and rebuilds
+// the search vectors when it differs from the one they were built with.
+func (r *SearchRepo) SyncLanguage(ctx context.Context) error {
+	defer metrics.ObserveQuery("search", "SyncLanguage")()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, "UPDATE search_settings SET language = $1::regconfig WHERE language <> $1::regconfig",
+		r.language)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	changed, err := res.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if changed == 0 {
+		return tx.Commit()
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE todo_lists SET search_vector = NULL"); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE todo_items SET search_vector = NULL"); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *SearchRepo) Search(ctx context.Context, userId int, input domain.SearchInput) ([]domain.SearchHit, error) {
+	defer metrics.ObserveQuery("search", "Search")()
+
+	var hits []domain.SearchHit
+
+	rows, err := r.db.QueryContext(ctx, `SELECT type, id, list_id, title, snippet, rank FROM (
+		SELECT 'list' AS type, tl.id, tl.id AS list_id, tl.title,
+		ts_headline($2::regconfig, translate(tl.title || ' ' || coalesce(tl.description, ''), $7, ''), q,
+			$8) AS snippet,
+		ts_rank(tl.search_vector, q) AS rank
+		FROM todo_lists tl
+		JOIN users_lists ul ON tl.id = ul.list_id,
+		websearch_to_tsquery($2::regconfig, $3) q
+		WHERE ul.user_id = $1 AND tl.deleted_at IS NULL AND tl.search_vector @@ q
+		AND ($4::int IS NULL OR tl.id = $4) AND $5::boolean IS NULL
+		UNION ALL
+		SELECT 'item', ti.id, li.list_id, ti.title,
+		ts_headline($2::regconfig, translate(ti.title || ' ' || coalesce(ti.description, ''), $7, ''), q,
+			$8),
+		ts_rank(ti.search_vector, q)
+		FROM todo_items ti
+		JOIN lists_items li ON ti.id = li.item_id
+		JOIN users_lists ul ON li.list_id = ul.list_id
+		JOIN todo_lists tl ON li.list_id = tl.id,
+		websearch_to_tsquery($2::regconfig, $3) q
+		WHERE ul.user_id = $1 AND ti.deleted_at IS NULL AND tl.deleted_at IS NULL AND ti.search_vector @@ q
+		AND ($4::int IS NULL OR li.list_id = $4) AND ($5::boolean IS NULL OR ti.done = $5)
+	) hits ORDER BY rank DESC LIMIT $6`,
+		userId, r.language, input.Query, input.ListId, input.Done, input.Limit,
+		headlineStart+headlineStop, headlineOptions)
+	if err != nil {
+		return hits, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hit domain.SearchHit
+
+		if err := rows.Scan(&hit.Type, &hit.Id, &hit.ListId, &hit.Title, &hit.Snippet, &hit.Rank); err != nil {
+			return hits, err
+		}
+		hit.Snippet = headlineMarks.Replace(html.EscapeString(hit.Snippet))
+
+		hits = append(hits, hit)
+	}
+
+	return hits, rows.Err()
+}
@@ -0,0 +1,45 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/internal/metrics"
+)
+
+type StatsRepo struct {
+	db *sql.DB
+}
+
+func NewStatsRepo(db *sql.DB) *StatsRepo {
+	return &StatsRepo{db: db}
+}
+
+// GetActiveUsers counts the users who changed lists or items within the
+// window.
+func (r *StatsRepo) GetActiveUsers(ctx context.Context, window time.Duration) (int, error) {
+	defer metrics.ObserveQuery("stats", "GetActiveUsers")()
+
+	var users int
+
+	row := r.db.QueryRowContext(ctx, `SELECT count(DISTINCT actor_id) FROM audit_events
+	WHERE created_at > now() - make_interval(secs => $1) AND actor_id IS NOT NULL`, window.Seconds())
+	err := row.Scan(&users)
+
+	return users, err
+}
+
+func (r *StatsRepo) GetItemsCreated(ctx context.Context, window time.Duration) (int, error) {
+	defer metrics.ObserveQuery("stats", "GetItemsCreated")()
+
+	var items int
+
+	row := r.db.QueryRowContext(ctx, `SELECT count(*) FROM audit_events
+	WHERE created_at > now() - make_interval(secs => $1) AND entity_type = $2 AND action = $3`,
+		window.Seconds(), domain.EntityTypeItem, domain.AuditActionCreate)
+	err := row.Scan(&items)
+
+	return items, err
+}
@@ -0,0 +1,108 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/internal/metrics"
+)
+
+type SyncRepo struct {
+	db *sql.DB
+}
+
+func NewSyncRepo(db *sql.DB) *SyncRepo {
+	return &SyncRepo{db: db}
+}
+
+// GetHorizon returns the cursor every change before which is committed:
+// the oldest transaction still running has no smaller id.
+func (r *SyncRepo) GetHorizon(ctx context.Context) (domain.SyncCursor, error) {
+	defer metrics.ObserveQuery("sync", "GetHorizon")()
+
+	var xmin string
+
+	row := r.db.QueryRowContext(ctx, "SELECT pg_snapshot_xmin(pg_current_snapshot())::text")
+	if err := row.Scan(&xmin); err != nil {
+		return domain.SyncCursor{}, err
+	}
+
+	xid, err := strconv.ParseUint(xmin, 10, 64)
+
+	return domain.SyncCursor{Xid: xid}, err
+}
+
+// GetChanges returns the user's lists, items and tombstones that changed
+// after the cursor and before the horizon, in change order. With
+// withDeleted unset deleted records are left out.
+func (r *SyncRepo) GetChanges(ctx context.Context, userId int, after, horizon domain.SyncCursor, withDeleted bool,
+	limit int) ([]domain.SyncRecord, error) {
+	defer metrics.ObserveQuery("sync", "GetChanges")()
+
+	var records []domain.SyncRecord
+
+	rows, err := r.db.QueryContext(ctx, `SELECT c.entity_type, c.id, c.change_xid::text, c.change_seq, c.deleted, c.list_id,
+	c.title, c.description, c.is_template, c.archived, c.done, c.version FROM (
+		SELECT 'list' AS entity_type, tl.id, tl.change_xid, tl.change_seq, tl.deleted_at IS NOT NULL AS deleted,
+		NULL::int AS list_id, tl.title, tl.description, tl.is_template,
+		tl.archived_at IS NOT NULL AS archived, false AS done, tl.version
+		FROM todo_lists tl
+		JOIN users_lists ul ON tl.id = ul.list_id
+		WHERE ul.user_id = $1
+		UNION ALL
+		SELECT 'item', ti.id, ti.change_xid, ti.change_seq, ti.deleted_at IS NOT NULL OR tl.deleted_at IS NOT NULL,
+		li.list_id, ti.title, ti.description, false, false, ti.done, ti.version
+		FROM todo_items ti
+		JOIN lists_items li ON ti.id = li.item_id
+		JOIN todo_lists tl ON li.list_id = tl.id
+		JOIN users_lists ul ON li.list_id = ul.list_id
+		WHERE ul.user_id = $1
+		UNION ALL
+		SELECT st.entity_type, st.entity_id, st.change_xid, st.change_seq, true,
+		NULL, '', NULL, false, false, false, 0
+		FROM sync_tombstones st
+		WHERE st.user_id = $1
+	) c
+	WHERE (c.change_xid, c.change_seq) > ($2::xid8, $3) AND c.change_xid < $4::xid8
+	AND ($5 OR NOT c.deleted)
+	ORDER BY c.change_xid, c.change_seq LIMIT $6`,
+		userId, strconv.FormatUint(after.Xid, 10), after.Seq, strconv.FormatUint(horizon.Xid, 10),
+		withDeleted, limit)
+	if err != nil {
+		return records, err
+	}
+
+	for rows.Next() {
+		var record domain.SyncRecord
+		var xid, title string
+		var description *string
+		var isTemplate, archived, done bool
+		var version int
+
+		if err := rows.Scan(&record.EntityType, &record.Id, &xid, &record.Cursor.Seq, &record.Deleted,
+			&record.ListId, &title, &description, &isTemplate, &archived, &done, &version); err != nil {
+			return records, err
+		}
+
+		record.Cursor.Xid, err = strconv.ParseUint(xid, 10, 64)
+		if err != nil {
+			return records, err
+		}
+
+		if !record.Deleted && record.EntityType == domain.EntityTypeList {
+			record.List = &domain.TodoList{Id: record.Id, Title: title, Description: description,
+				IsTemplate: isTemplate, Archived: archived, Version: version}
+		}
+
+		if !record.Deleted && record.EntityType == domain.EntityTypeItem {
+			record.Item = &domain.TodoItem{Id: record.Id, Title: title, Description: description,
+				Done: done, Version: version}
+		}
+
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
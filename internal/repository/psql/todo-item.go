@@ -1,11 +1,15 @@
 package psql
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/internal/metrics"
+	"github.com/lib/pq"
 )
 
 type TodoItemRepo struct {
@@ -16,44 +20,55 @@ func NewTodoItemRepo(db *sql.DB) *TodoItemRepo {
 	return &TodoItemRepo{db: db}
 }
 
-func (r *TodoItemRepo) CreateItem(listId int, todoItem domain.TodoItem) (int, error) {
-	tx, err := r.db.Begin()
+func (r *TodoItemRepo) CreateItem(ctx context.Context, userId, listId int, todoItem domain.TodoItem) (int, error) {
+	defer metrics.ObserveQuery("todo_item", "CreateItem")()
+
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return 0, err
 	}
 
 	var itemId int
-	row := tx.QueryRow("INSERT INTO todo_items (title, description) VALUES ($1, $2) RETURNING id",
+	row := tx.QueryRowContext(ctx, "INSERT INTO todo_items (title, description) VALUES ($1, $2) RETURNING id",
 		todoItem.Title, todoItem.Description)
 	if err := row.Scan(&itemId); err != nil {
 		tx.Rollback()
 		return 0, err
 	}
 
-	_, err = tx.Exec("INSERT INTO lists_items (item_id, list_id) VALUES ($1, $2)",
+	_, err = tx.ExecContext(ctx, "INSERT INTO lists_items (item_id, list_id) VALUES ($1, $2)",
 		itemId, listId)
 	if err != nil {
 		tx.Rollback()
 		return 0, err
 	}
 
+	if err := recordCreated(ctx, tx, userId, domain.EntityTypeItem, itemId, createdItemFields(listId, todoItem)); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
 	return itemId, tx.Commit()
 }
 
-func (r *TodoItemRepo) GetAllItems(userId, listId int) ([]domain.TodoItem, error) {
+func (r *TodoItemRepo) GetAllItems(ctx context.Context, userId, listId int) ([]domain.TodoItem, error) {
+	defer metrics.ObserveQuery("todo_item", "GetAllItems")()
+
 	var items []domain.TodoItem
 
-	rows, err := r.db.Query(`SELECT ti.id, ti.title, ti.description, ti.done FROM todo_items ti 
+	rows, err := r.db.QueryContext(ctx, `SELECT ti.id, ti.title, ti.description, ti.done, ti.version FROM todo_items ti 
 	JOIN lists_items li ON ti.id = li.item_id 
 	JOIN users_lists ul ON li.list_id = ul.list_id
-	WHERE ul.user_id=$1 AND li.list_id=$2`, userId, listId)
+	JOIN todo_lists tl ON li.list_id = tl.id
+	WHERE ul.user_id=$1 AND li.list_id=$2
+	AND ti.deleted_at IS NULL AND tl.deleted_at IS NULL`, userId, listId)
 	if err != nil {
 		return items, err
 	}
 
 	var item domain.TodoItem
 	for rows.Next() {
-		if err := rows.Scan(&item.Id, &item.Title, &item.Description, &item.Done); err != nil {
+		if err := rows.Scan(&item.Id, &item.Title, &item.Description, &item.Done, &item.Version); err != nil {
 			return items, err
 		}
 
@@ -63,21 +78,79 @@ func (r *TodoItemRepo) GetAllItems(userId, listId int) ([]domain.TodoItem, error
 	return items, nil
 }
 
-func (r *TodoItemRepo) GetItemById(userId, itemId int) (domain.TodoItem, error) {
+// GetItemsByLists returns the items of each of the lists the user can access, by list id.
+func (r *TodoItemRepo) GetItemsByLists(ctx context.Context, userId int,
+	listIds []int) (map[int][]domain.TodoItem, error) {
+	defer metrics.ObserveQuery("todo_item", "GetItemsByLists")()
+
+	items := make(map[int][]domain.TodoItem)
+
+	rows, err := r.db.QueryContext(ctx, `SELECT li.list_id, ti.id, ti.title, ti.description, ti.done, ti.version FROM todo_items ti
+	JOIN lists_items li ON ti.id = li.item_id
+	JOIN users_lists ul ON li.list_id = ul.list_id
+	JOIN todo_lists tl ON li.list_id = tl.id
+	WHERE ul.user_id = $1 AND li.list_id = ANY($2)
+	AND ti.deleted_at IS NULL AND tl.deleted_at IS NULL
+	ORDER BY ti.id`, userId, pq.Array(listIds))
+	if err != nil {
+		return items, err
+	}
+
+	for rows.Next() {
+		var listId int
+		var item domain.TodoItem
+
+		if err := rows.Scan(&listId, &item.Id, &item.Title, &item.Description, &item.Done, &item.Version); err != nil {
+			return items, err
+		}
+
+		items[listId] = append(items[listId], item)
+	}
+
+	return items, rows.Err()
+}
+
+func (r *TodoItemRepo) GetItemById(ctx context.Context, userId, itemId int) (domain.TodoItem, error) {
+	defer metrics.ObserveQuery("todo_item", "GetItemById")()
+
 	var item domain.TodoItem
 
-	row := r.db.QueryRow(`SELECT ti.id, ti.title, ti.description, ti.done FROM todo_items ti 
+	row := r.db.QueryRowContext(ctx, `SELECT ti.id, ti.title, ti.description, ti.done, ti.version FROM todo_items ti 
 	JOIN lists_items li ON ti.id = li.item_id 
 	JOIN users_lists ul ON li.list_id = ul.list_id
-	WHERE ul.user_id=$1 AND ti.id=$2`, userId, itemId)
-	if err := row.Scan(&item.Id, &item.Title, &item.Description, &item.Done); err != nil {
+	JOIN todo_lists tl ON li.list_id = tl.id
+	WHERE ul.user_id=$1 AND ti.id=$2
+	AND ti.deleted_at IS NULL AND tl.deleted_at IS NULL`, userId, itemId)
+	if err := row.Scan(&item.Id, &item.Title, &item.Description, &item.Done, &item.Version); err != nil {
 		return item, err
 	}
 
 	return item, nil
 }
 
-func (r *TodoItemRepo) UpdateItem(userId, itemId int, input domain.UpdateItemInput) error {
+func (r *TodoItemRepo) UpdateItem(ctx context.Context, userId, itemId int, input domain.UpdateItemInput) error {
+	defer metrics.ObserveQuery("todo_item", "UpdateItem")()
+
+	query, args := itemUpdateQuery(itemId, input)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = auditedItemChange(ctx, tx, userId, itemId, domain.AuditActionUpdate, input.Version, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, query, args...)
+		return err
+	})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func itemUpdateQuery(itemId int, input domain.UpdateItemInput) (string, []interface{}) {
 	setValues := make([]string, 0)
 	args := make([]interface{}, 0)
 	argId := 1
@@ -100,24 +173,139 @@ func (r *TodoItemRepo) UpdateItem(userId, itemId int, input domain.UpdateItemInp
 		argId++
 	}
 
-	args = append(args, userId, itemId)
+	args = append(args, itemId)
 
 	setQuery := strings.Join(setValues, ", ")
 
-	query := fmt.Sprintf(`UPDATE todo_items ti SET %s FROM lists_items li, users_lists ul
-	WHERE ti.id = li.item_id AND li.list_id = ul.list_id 
-	AND ul.user_id = $%d AND ti.id = $%d`, setQuery, argId, argId+1)
+	query := fmt.Sprintf(`UPDATE todo_items SET %s WHERE id = $%d`, setQuery, argId)
+
+	return query, args
+}
+
+func (r *TodoItemRepo) ReplaceItem(ctx context.Context, userId, itemId int, input domain.ReplaceItemInput) error {
+	defer metrics.ObserveQuery("todo_item", "ReplaceItem")()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = auditedItemChange(ctx, tx, userId, itemId, domain.AuditActionUpdate, input.Version, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "UPDATE todo_items SET title = $1, description = $2, done = $3 WHERE id = $4",
+			input.Title, input.Description, input.Done, itemId)
+		return err
+	})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *TodoItemRepo) DeleteItem(ctx context.Context, userId, itemId int, version domain.VersionMatch) error {
+	defer metrics.ObserveQuery("todo_item", "DeleteItem")()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = auditedItemChange(ctx, tx, userId, itemId, domain.AuditActionDelete, version, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "UPDATE todo_items SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL", itemId)
+		return err
+	})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *TodoItemRepo) MoveItem(ctx context.Context, userId, itemId, listId int) error {
+	defer metrics.ObserveQuery("todo_item", "MoveItem")()
+
+	return r.MoveItems(ctx, userId, []int{itemId}, listId)
+}
+
+func (r *TodoItemRepo) MoveItems(ctx context.Context, userId int, itemIds []int, listId int) error {
+	defer metrics.ObserveQuery("todo_item", "MoveItems")()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, itemId := range itemIds {
+		err := auditedItemChange(ctx, tx, userId, itemId, domain.AuditActionMove, nil, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "UPDATE lists_items SET list_id = $1 WHERE item_id = $2", listId, itemId)
+			return err
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			tx.Rollback()
+			return fmt.Errorf("item %d not found", itemId)
+		}
+
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *TodoItemRepo) CopyItem(ctx context.Context, userId, itemId, listId int) (int, error) {
+	defer metrics.ObserveQuery("todo_item", "CopyItem")()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
 
-	_, err := r.db.Exec(query, args...)
+	var item domain.TodoItem
+	row := tx.QueryRowContext(ctx, `INSERT INTO todo_items (title, description, done)
+	SELECT ti.title, ti.description, ti.done FROM todo_items ti
+	JOIN lists_items li ON ti.id = li.item_id
+	JOIN users_lists ul ON li.list_id = ul.list_id
+	JOIN todo_lists tl ON li.list_id = tl.id
+	WHERE ul.user_id=$1 AND ti.id=$2
+	AND ti.deleted_at IS NULL AND tl.deleted_at IS NULL
+	RETURNING id, title, description, done, version`, userId, itemId)
+	if err := row.Scan(&item.Id, &item.Title, &item.Description, &item.Done, &item.Version); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
 
-	return err
+	_, err = tx.ExecContext(ctx, "INSERT INTO lists_items (item_id, list_id) VALUES ($1, $2)",
+		item.Id, listId)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := recordCreated(ctx, tx, userId, domain.EntityTypeItem, item.Id, createdItemFields(listId, item)); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return item.Id, tx.Commit()
 }
 
-func (r *TodoItemRepo) DeleteItem(userId, itemId int) error {
-	_, err := r.db.Exec(`DELETE FROM todo_items ti
-	USING lists_items li, users_lists ul
-	WHERE ti.id = li.item_id AND li.list_id = ul.list_id 
-	AND ul.user_id=$1 AND ti.id=$2 `, userId, itemId)
+// auditedItemChange applies a mutation to an item the user has access to
+// and records it in the audit log as part of tx.
+func auditedItemChange(ctx context.Context, tx *sql.Tx, userId, itemId int, action string, version domain.VersionMatch,
+	apply func(tx *sql.Tx) error) error {
+	snapshot := func(tx *sql.Tx) (domain.AuditFields, error) {
+		return itemSnapshot(ctx, tx, userId, itemId, includesDeleted(action))
+	}
+
+	return auditedChange(ctx, tx, userId, domain.EntityTypeItem, itemId, action, snapshot, func(tx *sql.Tx) error {
+		if err := checkVersion(ctx, tx, domain.EntityTypeItem, itemId, version); err != nil {
+			return err
+		}
 
-	return err
+		return apply(tx)
+	})
 }
@@ -1,11 +1,14 @@
 package psql
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
 
 	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/internal/metrics"
+	"github.com/lib/pq"
 )
 
 type TodoListRepo struct {
@@ -16,36 +19,47 @@ func NewTodoListRepo(db *sql.DB) *TodoListRepo {
 	return &TodoListRepo{db: db}
 }
 
-func (r *TodoListRepo) CreateList(userId int, todoList domain.TodoList) (int, error) {
-	tx, err := r.db.Begin()
+func (r *TodoListRepo) CreateList(ctx context.Context, userId int, todoList domain.TodoList) (int, error) {
+	defer metrics.ObserveQuery("todo_list", "CreateList")()
+
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return 0, err
 	}
 
 	var listId int
-	row := tx.QueryRow("INSERT INTO todo_lists (title, description) VALUES ($1, $2) RETURNING id",
+	row := tx.QueryRowContext(ctx, "INSERT INTO todo_lists (title, description) VALUES ($1, $2) RETURNING id",
 		todoList.Title, todoList.Description)
 	if err := row.Scan(&listId); err != nil {
 		tx.Rollback()
 		return 0, err
 	}
 
-	_, err = tx.Exec("INSERT INTO users_lists (user_id, list_id) VALUES ($1, $2)",
-		userId, listId)
+	_, err = tx.ExecContext(ctx, "INSERT INTO users_lists (user_id, list_id, role) VALUES ($1, $2, $3)",
+		userId, listId, domain.ListRoleOwner)
 	if err != nil {
 		tx.Rollback()
 		return 0, err
 	}
 
+	if err := recordCreated(ctx, tx, userId, domain.EntityTypeList, listId, createdListFields(todoList)); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
 	return listId, tx.Commit()
 }
 
-func (r *TodoListRepo) GetAllLists(userId int) ([]domain.TodoList, error) {
+func (r *TodoListRepo) GetAllLists(ctx context.Context, userId int, withArchived bool) ([]domain.TodoList, error) {
+	defer metrics.ObserveQuery("todo_list", "GetAllLists")()
+
 	var lists []domain.TodoList
 
-	rows, err := r.db.Query(`SELECT tl.id, tl.title, tl.description FROM todo_lists tl 
+	rows, err := r.db.QueryContext(ctx, `SELECT tl.id, tl.title, tl.description, tl.is_template, tl.archived_at IS NOT NULL, tl.version
+							FROM todo_lists tl 
 							JOIN users_lists ul ON tl.id = ul.list_id 
-							WHERE ul.user_id = $1`, userId)
+							WHERE ul.user_id = $1 AND tl.deleted_at IS NULL
+							AND ($2 OR tl.archived_at IS NULL)`, userId, withArchived)
 	if err != nil {
 		return lists, err
 	}
@@ -53,7 +67,7 @@ func (r *TodoListRepo) GetAllLists(userId int) ([]domain.TodoList, error) {
 	for rows.Next() {
 		var list domain.TodoList
 
-		if err := rows.Scan(&list.Id, &list.Title, &list.Description); err != nil {
+		if err := rows.Scan(&list.Id, &list.Title, &list.Description, &list.IsTemplate, &list.Archived, &list.Version); err != nil {
 			return lists, err
 		}
 
@@ -63,20 +77,56 @@ func (r *TodoListRepo) GetAllLists(userId int) ([]domain.TodoList, error) {
 	return lists, nil
 }
 
-func (r *TodoListRepo) GetListById(userId, listId int) (domain.TodoList, error) {
+func (r *TodoListRepo) GetListById(ctx context.Context, userId, listId int) (domain.TodoList, error) {
+	defer metrics.ObserveQuery("todo_list", "GetListById")()
+
 	var list domain.TodoList
 
-	row := r.db.QueryRow(`SELECT tl.id, tl.title, tl.description FROM todo_lists tl 
+	row := r.db.QueryRowContext(ctx, `SELECT tl.id, tl.title, tl.description, tl.is_template, tl.archived_at IS NOT NULL, tl.version
+							FROM todo_lists tl 
 							JOIN users_lists ul ON tl.id = ul.list_id 
-							WHERE ul.user_id = $1 AND ul.list_id = $2`, userId, listId)
-	if err := row.Scan(&list.Id, &list.Title, &list.Description); err != nil {
+							WHERE ul.user_id = $1 AND ul.list_id = $2 AND tl.deleted_at IS NULL`, userId, listId)
+	if err := row.Scan(&list.Id, &list.Title, &list.Description, &list.IsTemplate, &list.Archived, &list.Version); err != nil {
 		return list, err
 	}
 
 	return list, nil
 }
 
-func (r *TodoListRepo) UpdateList(userId, listId int, input domain.UpdateListInput) error {
+// GetListsByItems returns the list of each of the items the user can access, by item id.
+func (r *TodoListRepo) GetListsByItems(ctx context.Context, userId int,
+	itemIds []int) (map[int]domain.TodoList, error) {
+	defer metrics.ObserveQuery("todo_list", "GetListsByItems")()
+
+	lists := make(map[int]domain.TodoList)
+
+	rows, err := r.db.QueryContext(ctx, `SELECT li.item_id, tl.id, tl.title, tl.description, tl.is_template,
+	tl.archived_at IS NOT NULL, tl.version FROM todo_lists tl
+	JOIN lists_items li ON tl.id = li.list_id
+	JOIN users_lists ul ON tl.id = ul.list_id
+	WHERE ul.user_id = $1 AND li.item_id = ANY($2) AND tl.deleted_at IS NULL`, userId, pq.Array(itemIds))
+	if err != nil {
+		return lists, err
+	}
+
+	for rows.Next() {
+		var itemId int
+		var list domain.TodoList
+
+		if err := rows.Scan(&itemId, &list.Id, &list.Title, &list.Description, &list.IsTemplate,
+			&list.Archived, &list.Version); err != nil {
+			return lists, err
+		}
+
+		lists[itemId] = list
+	}
+
+	return lists, rows.Err()
+}
+
+func (r *TodoListRepo) UpdateList(ctx context.Context, userId, listId int, input domain.UpdateListInput) error {
+	defer metrics.ObserveQuery("todo_list", "UpdateList")()
+
 	setValues := make([]string, 0)
 	args := make([]interface{}, 0)
 	argId := 1
@@ -93,21 +143,165 @@ func (r *TodoListRepo) UpdateList(userId, listId int, input domain.UpdateListInp
 		argId++
 	}
 
+	if input.IsTemplate != nil {
+		setValues = append(setValues, fmt.Sprintf("is_template=$%d", argId))
+		args = append(args, *input.IsTemplate)
+		argId++
+	}
+
 	setQuery := strings.Join(setValues, ", ")
 
-	query := fmt.Sprintf(`UPDATE todo_lists tl SET %s FROM users_lists ul 
-	WHERE tl.id = ul.list_id AND ul.user_id = $%d AND ul.list_id = $%d`, setQuery, argId, argId+1)
+	query := fmt.Sprintf(`UPDATE todo_lists SET %s WHERE id = $%d`, setQuery, argId)
+
+	args = append(args, listId)
+
+	return r.change(ctx, userId, listId, domain.AuditActionUpdate, input.Version, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, query, args...)
+		return err
+	})
+}
+
+func (r *TodoListRepo) ReplaceList(ctx context.Context, userId, listId int, input domain.ReplaceListInput) error {
+	defer metrics.ObserveQuery("todo_list", "ReplaceList")()
+
+	return r.change(ctx, userId, listId, domain.AuditActionUpdate, input.Version, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "UPDATE todo_lists SET title = $1, description = $2, is_template = $3 WHERE id = $4",
+			input.Title, input.Description, input.IsTemplate, listId)
+		return err
+	})
+}
+
+func (r *TodoListRepo) DeleteList(ctx context.Context, userId, listId int, version domain.VersionMatch) error {
+	defer metrics.ObserveQuery("todo_list", "DeleteList")()
+
+	return r.change(ctx, userId, listId, domain.AuditActionDelete, version, func(tx *sql.Tx) error {
+		if err := checkOwner(ctx, tx, userId, listId); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx, "UPDATE todo_lists SET deleted_at = now() WHERE id = $1 AND deleted_at IS NULL", listId)
+		return err
+	})
+}
+
+func (r *TodoListRepo) SetArchived(ctx context.Context, userId, listId int, archived bool) error {
+	defer metrics.ObserveQuery("todo_list", "SetArchived")()
+
+	return r.change(ctx, userId, listId, domain.AuditActionUpdate, nil, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `UPDATE todo_lists SET archived_at = CASE WHEN $2 THEN COALESCE(archived_at, now()) END
+		WHERE id = $1`, listId, archived)
+		return err
+	})
+}
+
+func (r *TodoListRepo) change(ctx context.Context, userId, listId int, action string, version domain.VersionMatch,
+	apply func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := auditedListChange(ctx, tx, userId, listId, action, version, apply); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// auditedListChange applies a mutation to a list the user has access to
+// and records it in the audit log as part of tx.
+func auditedListChange(ctx context.Context, tx *sql.Tx, userId, listId int, action string, version domain.VersionMatch,
+	apply func(tx *sql.Tx) error) error {
+	snapshot := func(tx *sql.Tx) (domain.AuditFields, error) {
+		return listSnapshot(ctx, tx, userId, listId, includesDeleted(action))
+	}
 
-	args = append(args, userId, listId)
+	return auditedChange(ctx, tx, userId, domain.EntityTypeList, listId, action, snapshot, func(tx *sql.Tx) error {
+		if err := checkVersion(ctx, tx, domain.EntityTypeList, listId, version); err != nil {
+			return err
+		}
 
-	_, err := r.db.Exec(query, args...)
+		return apply(tx)
+	})
+}
+
+func (r *TodoListRepo) GetTemplates(ctx context.Context, userId int) ([]domain.TodoList, error) {
+	defer metrics.ObserveQuery("todo_list", "GetTemplates")()
+
+	var lists []domain.TodoList
 
-	return err
+	rows, err := r.db.QueryContext(ctx, `SELECT tl.id, tl.title, tl.description, tl.is_template, tl.archived_at IS NOT NULL, tl.version
+							FROM todo_lists tl 
+							JOIN users_lists ul ON tl.id = ul.list_id 
+							WHERE ul.user_id = $1 AND tl.is_template AND tl.deleted_at IS NULL`, userId)
+	if err != nil {
+		return lists, err
+	}
+
+	for rows.Next() {
+		var list domain.TodoList
+
+		if err := rows.Scan(&list.Id, &list.Title, &list.Description, &list.IsTemplate, &list.Archived, &list.Version); err != nil {
+			return lists, err
+		}
+
+		lists = append(lists, list)
+	}
+
+	return lists, nil
 }
 
-func (r *TodoListRepo) DeleteList(userId, listId int) error {
-	_, err := r.db.Exec(`DELETE FROM todo_lists tl USING users_lists ul 
-						WHERE tl.id = ul.list_id AND ul.user_id = $1 AND ul.list_id = $2`, userId, listId)
+func (r *TodoListRepo) CreateListWithItems(ctx context.Context, userId int,
+	todoList domain.TodoList, items []domain.TodoItem) (int, error) {
+	defer metrics.ObserveQuery("todo_list", "CreateListWithItems")()
 
-	return err
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var listId int
+	row := tx.QueryRowContext(ctx, "INSERT INTO todo_lists (title, description, is_template) VALUES ($1, $2, $3) RETURNING id",
+		todoList.Title, todoList.Description, todoList.IsTemplate)
+	if err := row.Scan(&listId); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO users_lists (user_id, list_id, role) VALUES ($1, $2, $3)",
+		userId, listId, domain.ListRoleOwner)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := recordCreated(ctx, tx, userId, domain.EntityTypeList, listId, createdListFields(todoList)); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	for _, item := range items {
+		var itemId int
+		row := tx.QueryRowContext(ctx, "INSERT INTO todo_items (title, description, done) VALUES ($1, $2, $3) RETURNING id",
+			item.Title, item.Description, item.Done)
+		if err := row.Scan(&itemId); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+
+		_, err = tx.ExecContext(ctx, "INSERT INTO lists_items (item_id, list_id) VALUES ($1, $2)",
+			itemId, listId)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+
+		if err := recordCreated(ctx, tx, userId, domain.EntityTypeItem, itemId, createdItemFields(listId, item)); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
+	return listId, tx.Commit()
 }
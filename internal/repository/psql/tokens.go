@@ -1,9 +1,11 @@
 package psql
 
 import (
+	"context"
 	"database/sql"
 
 	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/internal/metrics"
 )
 
 type TokensRepo struct {
@@ -14,22 +16,26 @@ func NewTokensRepo(db *sql.DB) *TokensRepo {
 	return &TokensRepo{db: db}
 }
 
-func (r *TokensRepo) CreateSession(refreshToken domain.RefreshSession) error {
-	_, err := r.db.Exec("INSERT INTO refresh_tokens (user_id, token, expires_at) values ($1, $2, $3)",
+func (r *TokensRepo) CreateSession(ctx context.Context, refreshToken domain.RefreshSession) error {
+	defer metrics.ObserveQuery("tokens", "CreateSession")()
+
+	_, err := r.db.ExecContext(ctx, "INSERT INTO refresh_tokens (user_id, token, expires_at) values ($1, $2, $3)",
 		refreshToken.UserId, refreshToken.Token, refreshToken.ExpiresAt)
 
 	return err
 }
 
-func (r *TokensRepo) GetSession(refreshToken string) (domain.RefreshSession, error) {
+func (r *TokensRepo) GetSession(ctx context.Context, refreshToken string) (domain.RefreshSession, error) {
+	defer metrics.ObserveQuery("tokens", "GetSession")()
+
 	var session domain.RefreshSession
 
-	row := r.db.QueryRow("SELECT * FROM refresh_tokens WHERE token=$1", refreshToken)
+	row := r.db.QueryRowContext(ctx, "SELECT * FROM refresh_tokens WHERE token=$1", refreshToken)
 	if err := row.Scan(&session.Id, &session.UserId, &session.Token, &session.ExpiresAt); err != nil {
 		return session, err
 	}
 
-	_, err := r.db.Exec("DELETE FROM refresh_tokens WHERE user_id=$1", session.UserId)
+	_, err := r.db.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE user_id=$1", session.UserId)
 
 	return session, err
 }
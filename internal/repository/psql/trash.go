@@ -0,0 +1,146 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/internal/metrics"
+)
+
+type TrashRepo struct {
+	db *sql.DB
+}
+
+func NewTrashRepo(db *sql.DB) *TrashRepo {
+	return &TrashRepo{db: db}
+}
+
+func (r *TrashRepo) GetTrash(ctx context.Context, userId int) ([]domain.TrashEntry, error) {
+	defer metrics.ObserveQuery("trash", "GetTrash")()
+
+	var entries []domain.TrashEntry
+
+	rows, err := r.db.QueryContext(ctx, `SELECT 'list', tl.id, tl.title, tl.deleted_at FROM todo_lists tl
+	JOIN users_lists ul ON tl.id = ul.list_id
+	WHERE ul.user_id = $1 AND tl.deleted_at IS NOT NULL
+	UNION ALL
+	SELECT 'item', ti.id, ti.title, ti.deleted_at FROM todo_items ti
+	JOIN lists_items li ON ti.id = li.item_id
+	JOIN users_lists ul ON li.list_id = ul.list_id
+	JOIN todo_lists tl ON li.list_id = tl.id
+	WHERE ul.user_id = $1 AND ti.deleted_at IS NOT NULL AND tl.deleted_at IS NULL
+	ORDER BY 4 DESC`, userId)
+	if err != nil {
+		return entries, err
+	}
+
+	for rows.Next() {
+		var entry domain.TrashEntry
+
+		if err := rows.Scan(&entry.Type, &entry.Id, &entry.Title, &entry.DeletedAt); err != nil {
+			return entries, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func (r *TrashRepo) RestoreList(ctx context.Context, userId, listId int) error {
+	defer metrics.ObserveQuery("trash", "RestoreList")()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = auditedListChange(ctx, tx, userId, listId, domain.AuditActionRestore, nil, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "UPDATE todo_lists SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL", listId)
+		if err != nil {
+			return err
+		}
+
+		return checkRestored(res)
+	})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *TrashRepo) RestoreItem(ctx context.Context, userId, itemId int) error {
+	defer metrics.ObserveQuery("trash", "RestoreItem")()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = auditedItemChange(ctx, tx, userId, itemId, domain.AuditActionRestore, nil, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "UPDATE todo_items SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL", itemId)
+		if err != nil {
+			return err
+		}
+
+		return checkRestored(res)
+	})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *TrashRepo) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	defer metrics.ObserveQuery("trash", "Purge")()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	items, err := tx.ExecContext(ctx, `DELETE FROM todo_items ti USING lists_items li, todo_lists tl
+	WHERE ti.id = li.item_id AND li.list_id = tl.id
+	AND (ti.deleted_at < $1 OR tl.deleted_at < $1)`, deletedBefore)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	lists, err := tx.ExecContext(ctx, "DELETE FROM todo_lists WHERE deleted_at < $1", deletedBefore)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM sync_tombstones WHERE created_at < $1", deletedBefore)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	itemsPurged, _ := items.RowsAffected()
+	listsPurged, _ := lists.RowsAffected()
+
+	return itemsPurged + listsPurged, tx.Commit()
+}
+
+func checkRestored(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return errors.New("entry not found in trash")
+	}
+
+	return nil
+}
@@ -0,0 +1,247 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/internal/metrics"
+)
+
+// UndoEvent reverts an update, delete or move the user performed within
+// the window by restoring the fields recorded before it. The undo itself
+// is recorded as a new audit event.
+func (r *AuditRepo) UndoEvent(ctx context.Context, userId, eventId int, window time.Duration) error {
+	defer metrics.ObserveQuery("audit", "UndoEvent")()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	row := tx.QueryRowContext(ctx, `SELECT id, entity_type, entity_id, actor_id, action, before, after, request_id, created_at
+	FROM audit_events WHERE id = $1 AND actor_id = $2`, eventId, userId)
+	event, err := scanAuditEvent(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		tx.Rollback()
+		return domain.ErrUndoUnavailable
+	}
+
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if !undoable(event, time.Now(), window) {
+		tx.Rollback()
+		return domain.ErrUndoUnavailable
+	}
+
+	snapshot := func(tx *sql.Tx) (domain.AuditFields, error) {
+		if event.EntityType == domain.EntityTypeList {
+			return listSnapshot(ctx, tx, userId, event.EntityId, true)
+		}
+
+		return itemSnapshot(ctx, tx, userId, event.EntityId, true)
+	}
+
+	current, err := snapshot(tx)
+	if errors.Is(err, sql.ErrNoRows) {
+		tx.Rollback()
+		return domain.ErrEntityChanged
+	}
+
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var changed bool
+	row = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM audit_events
+	WHERE entity_type = $1 AND entity_id = $2 AND id > $3)`, event.EntityType, event.EntityId, event.Id)
+	if err := row.Scan(&changed); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if changed {
+		tx.Rollback()
+		return domain.ErrEntityChanged
+	}
+
+	unchanged, err := matchesFields(current, event.After)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if !unchanged {
+		tx.Rollback()
+		return domain.ErrEntityChanged
+	}
+
+	apply := func(tx *sql.Tx) error {
+		return restoreFields(ctx, tx, userId, event.EntityType, event.EntityId, event.Before)
+	}
+
+	err = auditedChange(ctx, tx, userId, event.EntityType, event.EntityId, domain.AuditActionUndo, snapshot, apply)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// undoable reports whether the event is an update, delete or move that
+// happened within the window before now.
+func undoable(event domain.AuditEvent, now time.Time, window time.Duration) bool {
+	switch event.Action {
+	case domain.AuditActionUpdate, domain.AuditActionDelete, domain.AuditActionMove:
+		return now.Sub(event.CreatedAt) < window
+	default:
+		return false
+	}
+}
+
+// matchesFields reports whether the current snapshot still holds the values
+// recorded for the fields, comparing them as they are stored in JSON.
+func matchesFields(current, recorded domain.AuditFields) (bool, error) {
+	subset := make(domain.AuditFields)
+	for field := range recorded {
+		subset[field] = current[field]
+	}
+
+	b, err := json.Marshal(subset)
+	if err != nil {
+		return false, err
+	}
+
+	var normalized domain.AuditFields
+	if err := json.Unmarshal(b, &normalized); err != nil {
+		return false, err
+	}
+
+	return reflect.DeepEqual(normalized, recorded), nil
+}
+
+var restorableColumns = map[string]map[string]string{
+	domain.EntityTypeList: {
+		"title":       "title=$%d",
+		"description": "description=$%d",
+		"is_template": "is_template=$%d",
+		"archived":    "archived_at = CASE WHEN $%d THEN COALESCE(archived_at, now()) END",
+		"deleted":     "deleted_at = CASE WHEN $%d THEN COALESCE(deleted_at, now()) END",
+	},
+	domain.EntityTypeItem: {
+		"title":       "title=$%d",
+		"description": "description=$%d",
+		"done":        "done=$%d",
+		"deleted":     "deleted_at = CASE WHEN $%d THEN COALESCE(deleted_at, now()) END",
+	},
+}
+
+func restoreFields(ctx context.Context, tx *sql.Tx, userId int, entityType string, entityId int,
+	fields domain.AuditFields) error {
+	query, args, err := restoreQuery(entityType, entityId, fields)
+	if err != nil {
+		return err
+	}
+
+	if value, ok := fields["list_id"]; ok && entityType == domain.EntityTypeItem {
+		listId, err := restoredListId(value)
+		if err != nil {
+			return err
+		}
+
+		if err := restoreItemList(ctx, tx, userId, entityId, listId); err != nil {
+			return err
+		}
+	}
+
+	if query == "" {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx, query, args...)
+
+	return err
+}
+
+// restoreQuery builds the update writing the fields back to the entity's
+// columns, or an empty query if there's nothing to write. An item's list_id
+// isn't a column and is left to restoreItemList.
+func restoreQuery(entityType string, entityId int, fields domain.AuditFields) (string, []interface{}, error) {
+	columns := restorableColumns[entityType]
+	setValues := make([]string, 0)
+	args := make([]interface{}, 0)
+	argId := 1
+
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+
+	for _, field := range names {
+		if field == "list_id" && entityType == domain.EntityTypeItem {
+			continue
+		}
+
+		column, ok := columns[field]
+		if !ok {
+			return "", nil, fmt.Errorf("field %q can't be restored", field)
+		}
+
+		setValues = append(setValues, fmt.Sprintf(column, argId))
+		args = append(args, fields[field])
+		argId++
+	}
+
+	if len(setValues) == 0 {
+		return "", nil, nil
+	}
+
+	args = append(args, entityId)
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE id = $%d", entityTable(entityType),
+		strings.Join(setValues, ", "), argId)
+
+	return query, args, nil
+}
+
+// restoredListId reads the list_id recorded for a move, which comes back
+// from JSON as a float64.
+func restoredListId(value interface{}) (int, error) {
+	id, ok := value.(float64)
+	if !ok || id != float64(int(id)) {
+		return 0, fmt.Errorf("invalid list_id %v", value)
+	}
+
+	return int(id), nil
+}
+
+func restoreItemList(ctx context.Context, tx *sql.Tx, userId, itemId, listId int) error {
+	var accessible bool
+	row := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM users_lists ul
+	JOIN todo_lists tl ON ul.list_id = tl.id
+	WHERE ul.user_id = $1 AND ul.list_id = $2 AND tl.deleted_at IS NULL)`, userId, listId)
+	if err := row.Scan(&accessible); err != nil {
+		return err
+	}
+
+	if !accessible {
+		return domain.ErrEntityChanged
+	}
+
+	_, err := tx.ExecContext(ctx, "UPDATE lists_items SET list_id = $1 WHERE item_id = $2", listId, itemId)
+
+	return err
+}
@@ -0,0 +1,159 @@
+package psql
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+)
+
+func TestUndoable(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	const window = 5 * time.Minute
+
+	tests := []struct {
+		name   string
+		action string
+		age    time.Duration
+		want   bool
+	}{
+		{name: "recent update", action: domain.AuditActionUpdate, age: time.Minute, want: true},
+		{name: "recent delete", action: domain.AuditActionDelete, age: time.Minute, want: true},
+		{name: "recent move", action: domain.AuditActionMove, age: time.Minute, want: true},
+		{name: "expired window", action: domain.AuditActionUpdate, age: 10 * time.Minute},
+		{name: "window just closed", action: domain.AuditActionUpdate, age: window},
+		{name: "create", action: domain.AuditActionCreate, age: time.Minute},
+		{name: "restore", action: domain.AuditActionRestore, age: time.Minute},
+		{name: "undo", action: domain.AuditActionUndo, age: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := domain.AuditEvent{Action: tt.action, CreatedAt: now.Add(-tt.age)}
+
+			if got := undoable(event, now, window); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchesFields(t *testing.T) {
+	current := domain.AuditFields{"title": "groceries", "done": true, "list_id": 3, "deleted": false}
+
+	tests := []struct {
+		name     string
+		recorded domain.AuditFields
+		want     bool
+	}{
+		{name: "unchanged", recorded: domain.AuditFields{"title": "groceries", "done": true}, want: true},
+		{name: "numbers read back from JSON", recorded: domain.AuditFields{"list_id": float64(3)}, want: true},
+		{name: "changed since the event", recorded: domain.AuditFields{"title": "chores", "done": true}},
+		{name: "moved since the event", recorded: domain.AuditFields{"list_id": float64(4)}},
+		{name: "restored since the delete", recorded: domain.AuditFields{"deleted": true}},
+		{name: "field missing from the snapshot", recorded: domain.AuditFields{"archived": false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matchesFields(current, tt.recorded)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRestoreQuery(t *testing.T) {
+	tests := []struct {
+		name       string
+		entityType string
+		fields     domain.AuditFields
+		wantQuery  string
+		wantArgs   []interface{}
+		wantErr    bool
+	}{
+		{
+			name:       "undone update",
+			entityType: domain.EntityTypeList,
+			fields:     domain.AuditFields{"title": "groceries", "description": "weekly"},
+			wantQuery:  "UPDATE todo_lists SET description=$1, title=$2 WHERE id = $3",
+			wantArgs:   []interface{}{"weekly", "groceries", 7},
+		},
+		{
+			name:       "undone delete",
+			entityType: domain.EntityTypeItem,
+			fields:     domain.AuditFields{"deleted": false},
+			wantQuery:  "UPDATE todo_items SET deleted_at = CASE WHEN $1 THEN COALESCE(deleted_at, now()) END WHERE id = $2",
+			wantArgs:   []interface{}{false, 7},
+		},
+		{
+			name:       "undone move",
+			entityType: domain.EntityTypeItem,
+			fields:     domain.AuditFields{"list_id": float64(3)},
+		},
+		{
+			name:       "undone move of a list",
+			entityType: domain.EntityTypeList,
+			fields:     domain.AuditFields{"list_id": float64(3)},
+			wantErr:    true,
+		},
+		{
+			name:       "unknown field",
+			entityType: domain.EntityTypeItem,
+			fields:     domain.AuditFields{"title": "milk", "owner": 1},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := restoreQuery(tt.entityType, 7, tt.fields)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error: %v", err, tt.wantErr)
+			}
+
+			if query != tt.wantQuery {
+				t.Errorf("got query %q, want %q", query, tt.wantQuery)
+			}
+
+			if len(args) != 0 || len(tt.wantArgs) != 0 {
+				if !reflect.DeepEqual(args, tt.wantArgs) {
+					t.Errorf("got args %v, want %v", args, tt.wantArgs)
+				}
+			}
+		})
+	}
+}
+
+func TestRestoredListId(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    int
+		wantErr bool
+	}{
+		{name: "number from JSON", value: float64(3), want: 3},
+		{name: "fraction", value: 3.5, wantErr: true},
+		{name: "string", value: "3", wantErr: true},
+		{name: "missing", value: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := restoredListId(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error: %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
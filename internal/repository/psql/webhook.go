@@ -0,0 +1,265 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/internal/metrics"
+	"github.com/lib/pq"
+)
+
+const webhookColumns = `id, url, event_types, list_id, disabled_at IS NULL, failure_count, created_at`
+
+const deliveryColumns = `id, webhook_id, event_id, event_type, status, attempts, response_code, error,
+	next_attempt_at, created_at, delivered_at`
+
+type WebhookRepo struct {
+	db *sql.DB
+}
+
+func NewWebhookRepo(db *sql.DB) *WebhookRepo {
+	return &WebhookRepo{db: db}
+}
+
+func (r *WebhookRepo) CreateWebhook(ctx context.Context, userId int, input domain.WebhookInput,
+	secret string) (int, error) {
+	defer metrics.ObserveQuery("webhook", "CreateWebhook")()
+
+	var webhookId int
+
+	row := r.db.QueryRowContext(ctx, `INSERT INTO webhooks (user_id, url, secret, event_types, list_id)
+	VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		userId, input.URL, secret, pq.Array(eventTypes(input.EventTypes)), input.ListId)
+	if err := row.Scan(&webhookId); err != nil {
+		return 0, err
+	}
+
+	return webhookId, nil
+}
+
+func (r *WebhookRepo) GetWebhooks(ctx context.Context, userId int) ([]domain.Webhook, error) {
+	defer metrics.ObserveQuery("webhook", "GetWebhooks")()
+
+	var webhooks []domain.Webhook
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE user_id = $1 ORDER BY id`, userId)
+	if err != nil {
+		return webhooks, err
+	}
+
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return webhooks, err
+		}
+
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+func (r *WebhookRepo) GetWebhookById(ctx context.Context, userId, webhookId int) (domain.Webhook, error) {
+	defer metrics.ObserveQuery("webhook", "GetWebhookById")()
+
+	row := r.db.QueryRowContext(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE user_id = $1 AND id = $2`,
+		userId, webhookId)
+
+	return scanWebhook(row)
+}
+
+// UpdateWebhook replaces the webhook's settings. Activating it clears its
+// failure count, deactivating it keeps its deliveries queued until then.
+func (r *WebhookRepo) UpdateWebhook(ctx context.Context, userId, webhookId int, input domain.WebhookInput) error {
+	defer metrics.ObserveQuery("webhook", "UpdateWebhook")()
+
+	_, err := r.db.ExecContext(ctx, `UPDATE webhooks SET url = $3, event_types = $4, list_id = $5,
+	failure_count = CASE WHEN $6::boolean THEN 0 ELSE failure_count END,
+	disabled_at = CASE WHEN $6::boolean THEN NULL
+		WHEN NOT $6::boolean THEN COALESCE(disabled_at, now())
+		ELSE disabled_at END
+	WHERE user_id = $1 AND id = $2`,
+		userId, webhookId, input.URL, pq.Array(eventTypes(input.EventTypes)), input.ListId, input.Active)
+
+	return err
+}
+
+func (r *WebhookRepo) DeleteWebhook(ctx context.Context, userId, webhookId int) error {
+	defer metrics.ObserveQuery("webhook", "DeleteWebhook")()
+
+	_, err := r.db.ExecContext(ctx, "DELETE FROM webhooks WHERE user_id = $1 AND id = $2", userId, webhookId)
+
+	return err
+}
+
+// CreateTestDelivery queues a ping event for the webhook.
+func (r *WebhookRepo) CreateTestDelivery(ctx context.Context, userId, webhookId int) (int, error) {
+	defer metrics.ObserveQuery("webhook", "CreateTestDelivery")()
+
+	var deliveryId int
+
+	row := r.db.QueryRowContext(ctx, `INSERT INTO webhook_deliveries (webhook_id, event_type)
+	SELECT id, $3 FROM webhooks WHERE user_id = $1 AND id = $2 RETURNING id`,
+		userId, webhookId, domain.WebhookEventPing)
+	if err := row.Scan(&deliveryId); err != nil {
+		return 0, err
+	}
+
+	return deliveryId, nil
+}
+
+func (r *WebhookRepo) GetDeliveries(ctx context.Context, userId, webhookId int,
+	page domain.Pagination) ([]domain.WebhookDelivery, error) {
+	defer metrics.ObserveQuery("webhook", "GetDeliveries")()
+
+	var deliveries []domain.WebhookDelivery
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+deliveryColumns+` FROM webhook_deliveries
+	WHERE webhook_id = (SELECT id FROM webhooks WHERE user_id = $1 AND id = $2)
+	ORDER BY id DESC LIMIT $3 OFFSET $4`, userId, webhookId, page.Limit, page.Offset)
+	if err != nil {
+		return deliveries, err
+	}
+
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return deliveries, err
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// ClaimDeliveries returns up to limit deliveries that are due and postpones
+// them by lease, so other instances don't send them meanwhile. Disabled
+// webhooks only get test events.
+func (r *WebhookRepo) ClaimDeliveries(ctx context.Context, limit int,
+	lease time.Duration) ([]domain.PendingDelivery, error) {
+	defer metrics.ObserveQuery("webhook", "ClaimDeliveries")()
+
+	var deliveries []domain.PendingDelivery
+
+	rows, err := r.db.QueryContext(ctx, `UPDATE webhook_deliveries d SET next_attempt_at = now() + make_interval(secs => $3)
+	FROM webhooks w
+	WHERE d.webhook_id = w.id AND d.id IN (
+		SELECT pd.id FROM webhook_deliveries pd
+		JOIN webhooks pw ON pd.webhook_id = pw.id
+		WHERE pd.status = $1 AND pd.next_attempt_at <= now()
+		AND (pw.disabled_at IS NULL OR pd.event_type = $4)
+		ORDER BY pd.next_attempt_at LIMIT $2
+		FOR UPDATE OF pd SKIP LOCKED)
+	RETURNING d.id, d.webhook_id, d.event_id, d.event_type, d.attempts, d.created_at, w.url, w.secret`,
+		domain.WebhookDeliveryPending, limit, lease.Seconds(), domain.WebhookEventPing)
+	if err != nil {
+		return deliveries, err
+	}
+
+	for rows.Next() {
+		var d domain.PendingDelivery
+
+		if err := rows.Scan(&d.Id, &d.WebhookId, &d.EventId, &d.EventType, &d.Attempts, &d.CreatedAt,
+			&d.URL, &d.Secret); err != nil {
+			return deliveries, err
+		}
+
+		d.Status = domain.WebhookDeliveryPending
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func (r *WebhookRepo) RecordSuccess(ctx context.Context, delivery domain.PendingDelivery,
+	result domain.DeliveryResult) error {
+	defer metrics.ObserveQuery("webhook", "RecordSuccess")()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE webhook_deliveries SET status = $2, attempts = attempts + 1,
+	response_code = $3, error = NULL, delivered_at = now() WHERE id = $1`,
+		delivery.Id, domain.WebhookDeliverySucceeded, result.ResponseCode)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE webhooks SET failure_count = 0 WHERE id = $1", delivery.WebhookId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RecordFailure schedules the delivery's next attempt in retryIn, or marks
+// it failed when giveUp is set. The webhook is disabled once it has failed
+// disableAfter times in a row.
+func (r *WebhookRepo) RecordFailure(ctx context.Context, delivery domain.PendingDelivery, result domain.DeliveryResult,
+	retryIn time.Duration, giveUp bool, disableAfter int) error {
+	defer metrics.ObserveQuery("webhook", "RecordFailure")()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	status := domain.WebhookDeliveryPending
+	if giveUp {
+		status = domain.WebhookDeliveryFailed
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE webhook_deliveries SET status = $2, attempts = attempts + 1,
+	response_code = $3, error = $4, next_attempt_at = now() + make_interval(secs => $5) WHERE id = $1`,
+		delivery.Id, status, result.ResponseCode, result.Error, retryIn.Seconds())
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE webhooks SET failure_count = failure_count + 1,
+	disabled_at = CASE WHEN failure_count + 1 >= $2 THEN COALESCE(disabled_at, now()) ELSE disabled_at END
+	WHERE id = $1`, delivery.WebhookId, disableAfter)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func scanWebhook(row scanner) (domain.Webhook, error) {
+	var webhook domain.Webhook
+
+	err := row.Scan(&webhook.Id, &webhook.URL, pq.Array(&webhook.EventTypes), &webhook.ListId,
+		&webhook.Active, &webhook.FailureCount, &webhook.CreatedAt)
+
+	return webhook, err
+}
+
+func scanDelivery(row scanner) (domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+
+	err := row.Scan(&delivery.Id, &delivery.WebhookId, &delivery.EventId, &delivery.EventType, &delivery.Status,
+		&delivery.Attempts, &delivery.ResponseCode, &delivery.Error, &delivery.NextAttemptAt,
+		&delivery.CreatedAt, &delivery.DeliveredAt)
+
+	return delivery, err
+}
+
+// eventTypes keeps an empty subscription from being stored as NULL.
+func eventTypes(types []string) []string {
+	if types == nil {
+		return []string{}
+	}
+
+	return types
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/pkg/storage"
+	"github.com/sirupsen/logrus"
+)
+
+const orphanedBlobsBatch = 100
+
+type Attachment interface {
+	CreateAttachment(ctx context.Context, attachment domain.Attachment) (int, error)
+	GetAttachments(ctx context.Context, userId, itemId int) ([]domain.Attachment, error)
+	GetAttachmentById(ctx context.Context, attachmentId int) (domain.Attachment, error)
+	DeleteAttachment(ctx context.Context, userId, attachmentId int) error
+	GetOrphanedBlobs(ctx context.Context, limit int) ([]string, error)
+	DeleteOrphanedBlob(ctx context.Context, key string) error
+}
+
+type AttachmentConfig struct {
+	MaxSize      int64
+	AllowedTypes []string
+	URLTTL       time.Duration
+	SigningKey   []byte
+}
+
+type AttachmentService struct {
+	repo     Attachment
+	itemRepo TodoItem
+	store    storage.BlobStore
+	cfg      AttachmentConfig
+}
+
+func NewAttachmentService(repo Attachment, itemRepo TodoItem, store storage.BlobStore,
+	cfg AttachmentConfig) *AttachmentService {
+	return &AttachmentService{repo: repo, itemRepo: itemRepo, store: store, cfg: cfg}
+}
+
+func (s *AttachmentService) MaxSize() int64 {
+	return s.cfg.MaxSize
+}
+
+func (s *AttachmentService) CreateAttachment(ctx context.Context, userId, itemId int, fileName string,
+	size int64, file io.Reader) (domain.Attachment, error) {
+	ctx, span := tracer.Start(ctx, "AttachmentService.CreateAttachment")
+	defer span.End()
+
+	attachment := domain.Attachment{ItemId: itemId, UserId: userId, FileName: fileName, Size: size}
+
+	if size > s.cfg.MaxSize {
+		return attachment, domain.ErrAttachmentTooLarge
+	}
+
+	if _, err := s.itemRepo.GetItemById(ctx, userId, itemId); err != nil {
+		return attachment, err
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return attachment, err
+	}
+	head = head[:n]
+
+	attachment.ContentType = http.DetectContentType(head)
+	if !s.allowedType(attachment.ContentType) {
+		return attachment, domain.ErrUnsupportedMediaType
+	}
+
+	attachment.StorageKey, err = newStorageKey(itemId)
+	if err != nil {
+		return attachment, err
+	}
+
+	body := io.MultiReader(bytes.NewReader(head), file)
+	if err := s.store.Put(ctx, attachment.StorageKey, body, size, attachment.ContentType); err != nil {
+		return attachment, err
+	}
+
+	attachment.Id, err = s.repo.CreateAttachment(ctx, attachment)
+	if err != nil {
+		s.store.Delete(ctx, attachment.StorageKey)
+		return attachment, err
+	}
+
+	attachment.CreatedAt = time.Now()
+	attachment.URL = s.signedURL(attachment.Id)
+
+	return attachment, nil
+}
+
+func (s *AttachmentService) GetAttachments(ctx context.Context, userId, itemId int) ([]domain.Attachment, error) {
+	ctx, span := tracer.Start(ctx, "AttachmentService.GetAttachments")
+	defer span.End()
+
+	attachments, err := s.repo.GetAttachments(ctx, userId, itemId)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range attachments {
+		attachments[i].URL = s.signedURL(attachments[i].Id)
+	}
+
+	return attachments, nil
+}
+
+func (s *AttachmentService) DeleteAttachment(ctx context.Context, userId, attachmentId int) error {
+	ctx, span := tracer.Start(ctx, "AttachmentService.DeleteAttachment")
+	defer span.End()
+
+	return s.repo.DeleteAttachment(ctx, userId, attachmentId)
+}
+
+// OpenAttachment checks a download signature and opens the attachment blob.
+func (s *AttachmentService) OpenAttachment(ctx context.Context, attachmentId int, expires int64,
+	signature string) (domain.Attachment, io.ReadCloser, error) {
+	ctx, span := tracer.Start(ctx, "AttachmentService.OpenAttachment")
+	defer span.End()
+
+	if time.Now().Unix() > expires || !hmac.Equal([]byte(signature), []byte(s.sign(attachmentId, expires))) {
+		return domain.Attachment{}, nil, domain.ErrInvalidSignature
+	}
+
+	attachment, err := s.repo.GetAttachmentById(ctx, attachmentId)
+	if err != nil {
+		return attachment, nil, err
+	}
+
+	blob, err := s.store.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		return attachment, nil, err
+	}
+
+	return attachment, blob, nil
+}
+
+// RunCleanup removes blobs whose attachments were deleted, either directly
+// or along with their item, every interval until ctx is cancelled.
+func (s *AttachmentService) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.cleanupBlobs(ctx); err != nil {
+				logrus.WithField("job", "blob_cleanup").Error(err)
+			}
+		}
+	}
+}
+
+func (s *AttachmentService) cleanupBlobs(ctx context.Context) error {
+	keys, err := s.repo.GetOrphanedBlobs(ctx, orphanedBlobsBatch)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if err := s.store.Delete(ctx, key); err != nil {
+			return err
+		}
+
+		if err := s.repo.DeleteOrphanedBlob(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *AttachmentService) allowedType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, t := range s.cfg.AllowedTypes {
+		if t == mediaType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *AttachmentService) signedURL(attachmentId int) string {
+	expires := time.Now().Add(s.cfg.URLTTL).Unix()
+
+	return fmt.Sprintf("/attachments/%d/download?expires=%d&signature=%s",
+		attachmentId, expires, s.sign(attachmentId, expires))
+}
+
+func (s *AttachmentService) sign(attachmentId int, expires int64) string {
+	mac := hmac.New(sha256.New, s.cfg.SigningKey)
+	mac.Write([]byte(strconv.Itoa(attachmentId) + ":" + strconv.FormatInt(expires, 10)))
+
+	return fmt.Sprintf("%x", mac.Sum(nil))
+}
+
+func newStorageKey(itemId int) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("items/%d/%x", itemId, b), nil
+}
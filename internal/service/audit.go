@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+)
+
+type Audit interface {
+	GetListHistory(ctx context.Context, userId, listId int, page domain.Pagination) ([]domain.AuditEvent, error)
+	GetItemHistory(ctx context.Context, userId, itemId int, page domain.Pagination) ([]domain.AuditEvent, error)
+	GetEvents(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEvent, error)
+	UndoEvent(ctx context.Context, userId, eventId int, window time.Duration) error
+}
+
+type AuditService struct {
+	repo       Audit
+	undoWindow time.Duration
+}
+
+func NewAuditService(repo Audit, undoWindow time.Duration) *AuditService {
+	return &AuditService{repo: repo, undoWindow: undoWindow}
+}
+
+func (s *AuditService) GetListHistory(ctx context.Context, userId, listId int,
+	page domain.Pagination) ([]domain.AuditEvent, error) {
+	ctx, span := tracer.Start(ctx, "AuditService.GetListHistory")
+	defer span.End()
+
+	page.Normalize()
+	return s.repo.GetListHistory(ctx, userId, listId, page)
+}
+
+func (s *AuditService) GetItemHistory(ctx context.Context, userId, itemId int,
+	page domain.Pagination) ([]domain.AuditEvent, error) {
+	ctx, span := tracer.Start(ctx, "AuditService.GetItemHistory")
+	defer span.End()
+
+	page.Normalize()
+	return s.repo.GetItemHistory(ctx, userId, itemId, page)
+}
+
+func (s *AuditService) GetEvents(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEvent, error) {
+	ctx, span := tracer.Start(ctx, "AuditService.GetEvents")
+	defer span.End()
+
+	filter.Normalize()
+	return s.repo.GetEvents(ctx, filter)
+}
+
+func (s *AuditService) Undo(ctx context.Context, userId, eventId int) error {
+	ctx, span := tracer.Start(ctx, "AuditService.Undo")
+	defer span.End()
+
+	return s.repo.UndoEvent(ctx, userId, eventId, s.undoWindow)
+}
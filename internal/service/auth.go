@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -8,17 +9,20 @@ import (
 	"time"
 
 	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/internal/metrics"
 	"github.com/golang-jwt/jwt"
 )
 
 type AuthRepo interface {
-	CreateUser(user domain.User) (int, error)
-	GetUserId(email, password string) (int, error)
+	CreateUser(ctx context.Context, user domain.User) (int, error)
+	GetUserId(ctx context.Context, email, password string) (int, error)
+	IsAdmin(ctx context.Context, userId int) (bool, error)
+	GetUserById(ctx context.Context, userId int) (domain.User, error)
 }
 
 type TokensRepo interface {
-	CreateSession(refreshToken domain.RefreshSession) error
-	GetSession(refreshToken string) (domain.RefreshSession, error)
+	CreateSession(ctx context.Context, refreshToken domain.RefreshSession) error
+	GetSession(ctx context.Context, refreshToken string) (domain.RefreshSession, error)
 }
 
 type PasswordHash interface {
@@ -39,7 +43,10 @@ func NewAuthService(repo AuthRepo, tokensRepo TokensRepo, hasher PasswordHash,
 		tokenTTL: tokenTTL, signingKey: signingKey}
 }
 
-func (s *AuthService) SignUp(user domain.User) (int, error) {
+func (s *AuthService) SignUp(ctx context.Context, user domain.User) (int, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.SignUp")
+	defer span.End()
+
 	passwordHash, err := s.hasher.Hash(user.PasswordHash)
 	if err != nil {
 		return 0, err
@@ -47,24 +54,36 @@ func (s *AuthService) SignUp(user domain.User) (int, error) {
 
 	user.PasswordHash = passwordHash
 	user.Registered = time.Now()
-	return s.repo.CreateUser(user)
+
+	id, err := s.repo.CreateUser(ctx, user)
+	if err != nil {
+		return 0, err
+	}
+
+	metrics.AuthEvent(metrics.AuthSignUp)
+	return id, nil
 }
 
-func (s *AuthService) SignIn(user domain.SignInInput) (string, string, error) {
+func (s *AuthService) SignIn(ctx context.Context, user domain.SignInInput) (string, string, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.SignIn")
+	defer span.End()
+
 	passwordHash, err := s.hasher.Hash(user.Password)
 	if err != nil {
 		return "", "", err
 	}
 
-	userId, err := s.repo.GetUserId(user.Email, passwordHash)
+	userId, err := s.repo.GetUserId(ctx, user.Email, passwordHash)
 	if err != nil {
+		metrics.AuthEvent(metrics.AuthSignInFailure)
 		return "", "", err
 	}
 
-	return s.generateTokens(userId)
+	metrics.AuthEvent(metrics.AuthSignIn)
+	return s.generateTokens(ctx, userId)
 }
 
-func (s *AuthService) generateTokens(userId int) (string, string, error) {
+func (s *AuthService) generateTokens(ctx context.Context, userId int) (string, string, error) {
 	t := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{
 		Subject:   strconv.Itoa(userId),
 		ExpiresAt: time.Now().Add(s.tokenTTL).Unix(),
@@ -80,7 +99,7 @@ func (s *AuthService) generateTokens(userId int) (string, string, error) {
 		return "", "", err
 	}
 
-	if err := s.tokensRepo.CreateSession(domain.RefreshSession{
+	if err := s.tokensRepo.CreateSession(ctx, domain.RefreshSession{
 		UserId:    userId,
 		Token:     refreshToken,
 		ExpiresAt: time.Now().Add(24 * time.Hour * 30),
@@ -116,18 +135,38 @@ func (s *AuthService) ParseToken(accesToken string) (int, error) {
 	return id, nil
 }
 
-func (s *AuthService) RefreshToken(refreshToken string) (string, string, error) {
-	token, err := s.tokensRepo.GetSession(refreshToken)
+func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (string, string, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.RefreshToken")
+	defer span.End()
+
+	token, err := s.tokensRepo.GetSession(ctx, refreshToken)
 	if err != nil {
+		metrics.AuthEvent(metrics.AuthRefreshFailure)
 		return "", "", err
 	}
 
 	if token.ExpiresAt.Unix() < time.Now().Unix() {
+		metrics.AuthEvent(metrics.AuthRefreshFailure)
 		return "", "", errors.New("refresh token expired")
 
 	}
 
-	return s.generateTokens(token.UserId)
+	metrics.AuthEvent(metrics.AuthRefresh)
+	return s.generateTokens(ctx, token.UserId)
+}
+
+func (s *AuthService) GetUser(ctx context.Context, userId int) (domain.User, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.GetUser")
+	defer span.End()
+
+	return s.repo.GetUserById(ctx, userId)
+}
+
+func (s *AuthService) IsAdmin(ctx context.Context, userId int) (bool, error) {
+	ctx, span := tracer.Start(ctx, "AuthService.IsAdmin")
+	defer span.End()
+
+	return s.repo.IsAdmin(ctx, userId)
 }
 
 func newRefreshToken() (string, error) {
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"github.com/SavelyDev/crud-app/internal/domain"
+)
+
+type Comment interface {
+	CreateComment(ctx context.Context, userId, itemId int, input domain.CommentInput) (int, error)
+	GetComments(ctx context.Context, userId, itemId int, page domain.Pagination) ([]domain.Comment, error)
+	UpdateComment(ctx context.Context, userId, commentId int, input domain.CommentInput) error
+	DeleteComment(ctx context.Context, userId, commentId int) error
+}
+
+type CommentService struct {
+	repo Comment
+}
+
+func NewCommentService(repo Comment) *CommentService {
+	return &CommentService{repo: repo}
+}
+
+func (s *CommentService) CreateComment(ctx context.Context, userId, itemId int,
+	input domain.CommentInput) (int, error) {
+	ctx, span := tracer.Start(ctx, "CommentService.CreateComment")
+	defer span.End()
+
+	return s.repo.CreateComment(ctx, userId, itemId, input)
+}
+
+func (s *CommentService) GetComments(ctx context.Context, userId, itemId int,
+	page domain.Pagination) ([]domain.Comment, error) {
+	ctx, span := tracer.Start(ctx, "CommentService.GetComments")
+	defer span.End()
+
+	page.Normalize()
+	return s.repo.GetComments(ctx, userId, itemId, page)
+}
+
+func (s *CommentService) UpdateComment(ctx context.Context, userId, commentId int, input domain.CommentInput) error {
+	ctx, span := tracer.Start(ctx, "CommentService.UpdateComment")
+	defer span.End()
+
+	return s.repo.UpdateComment(ctx, userId, commentId, input)
+}
+
+func (s *CommentService) DeleteComment(ctx context.Context, userId, commentId int) error {
+	ctx, span := tracer.Start(ctx, "CommentService.DeleteComment")
+	defer span.End()
+
+	return s.repo.DeleteComment(ctx, userId, commentId)
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/sirupsen/logrus"
+)
+
+type Events interface {
+	Listen(ctx context.Context, notify func(eventId int)) error
+	GetChangeEvent(ctx context.Context, eventId int) (domain.ChangeEvent, error)
+	GetChangeEventsAfter(ctx context.Context, eventId, limit int) ([]domain.ChangeEvent, error)
+	GetUserChangeEventsAfter(ctx context.Context, userId, eventId, limit int) ([]domain.ChangeEvent, error)
+}
+
+type subscriber struct {
+	userId int
+	events chan domain.ChangeEvent
+}
+
+// EventService fans change events out to the subscribers of this instance.
+// Every instance listens for the events committed by all of them, so
+// subscribers see changes no matter which instance made them.
+type EventService struct {
+	repo       Events
+	bufferSize int
+
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+	lastEventId int
+}
+
+func NewEventService(repo Events, bufferSize int) *EventService {
+	return &EventService{
+		repo:        repo,
+		bufferSize:  bufferSize,
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+// Subscribe returns the user's change events and a function that stops them.
+// A subscriber that can't keep up has its channel closed and should
+// reconnect, resuming from the last event it received.
+func (s *EventService) Subscribe(userId int) (<-chan domain.ChangeEvent, func()) {
+	sub := &subscriber{userId: userId, events: make(chan domain.ChangeEvent, s.bufferSize)}
+
+	s.mu.Lock()
+	s.subscribers[sub] = struct{}{}
+	s.mu.Unlock()
+
+	return sub.events, func() { s.unsubscribe(sub) }
+}
+
+func (s *EventService) unsubscribe(sub *subscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subscribers[sub]; ok {
+		delete(s.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// Replay returns the user's events after eventId. It reports whether there
+// were more than domain.MaxReplayEvents of them, in which case the client
+// should reload instead.
+func (s *EventService) Replay(ctx context.Context, userId, eventId int) ([]domain.ChangeEvent, bool, error) {
+	ctx, span := tracer.Start(ctx, "EventService.Replay")
+	defer span.End()
+
+	events, err := s.repo.GetUserChangeEventsAfter(ctx, userId, eventId, domain.MaxReplayEvents+1)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(events) > domain.MaxReplayEvents {
+		return events[:domain.MaxReplayEvents], true, nil
+	}
+
+	return events, false, nil
+}
+
+func (s *EventService) Publish(event domain.ChangeEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for sub := range s.subscribers {
+		if !slices.Contains(event.UserIds, sub.userId) {
+			continue
+		}
+
+		select {
+		case sub.events <- event:
+		default:
+			delete(s.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+// Run publishes the events committed by every app instance until ctx is
+// cancelled, reconnecting to the database when listening fails. Once ctx is
+// cancelled all subscriptions are closed.
+func (s *EventService) Run(ctx context.Context) {
+	for {
+		err := s.repo.Listen(ctx, func(eventId int) {
+			s.handleNotification(ctx, eventId)
+		})
+		if err != nil {
+			logrus.WithField("job", "change_events").Error(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			s.closeAll()
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// closeAll ends every subscription, so streams finish on shutdown.
+func (s *EventService) closeAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for sub := range s.subscribers {
+		delete(s.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+func (s *EventService) handleNotification(ctx context.Context, eventId int) {
+	var events []domain.ChangeEvent
+	var err error
+
+	if eventId == 0 {
+		if s.lastEventId == 0 {
+			return
+		}
+
+		events, err = s.repo.GetChangeEventsAfter(ctx, s.lastEventId, domain.MaxReplayEvents)
+	} else {
+		var event domain.ChangeEvent
+		event, err = s.repo.GetChangeEvent(ctx, eventId)
+		events = append(events, event)
+	}
+
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"job":      "change_events",
+			"event_id": eventId,
+		}).Error(err)
+		return
+	}
+
+	for _, event := range events {
+		if event.Id > s.lastEventId {
+			s.lastEventId = event.Id
+		}
+
+		s.Publish(event)
+	}
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+)
+
+type DatabaseHealth interface {
+	Ping(ctx context.Context) error
+	CheckMigrations(ctx context.Context) error
+}
+
+type HealthService struct {
+	db       DatabaseHealth
+	workers  *Workers
+	draining atomic.Bool
+}
+
+func NewHealthService(db DatabaseHealth, workers *Workers) *HealthService {
+	return &HealthService{db: db, workers: workers}
+}
+
+// Drain fails the readiness check from now on, so that load balancers stop
+// sending requests before the server shuts down.
+func (s *HealthService) Drain() {
+	s.draining.Store(true)
+}
+
+// Ready checks the database, its migrations and the background jobs.
+func (s *HealthService) Ready(ctx context.Context) domain.HealthReport {
+	if s.draining.Load() {
+		return domain.HealthReport{
+			Status: domain.HealthStatusUnavailable,
+			Checks: map[string]string{"shutdown": "draining"},
+		}
+	}
+
+	checks := make(map[string]string, 3)
+
+	checks["database"] = checkResult(s.db.Ping(ctx))
+	if checks["database"] == domain.HealthStatusOK {
+		checks["migrations"] = checkResult(s.db.CheckMigrations(ctx))
+	}
+
+	checks["workers"] = domain.HealthStatusOK
+	if stopped := s.workers.Stopped(); len(stopped) > 0 {
+		checks["workers"] = "stopped: " + strings.Join(stopped, ", ")
+	}
+
+	report := domain.HealthReport{Status: domain.HealthStatusOK, Checks: checks}
+	for _, result := range checks {
+		if result != domain.HealthStatusOK {
+			report.Status = domain.HealthStatusUnavailable
+		}
+	}
+
+	return report
+}
+
+func checkResult(err error) string {
+	if err != nil {
+		return err.Error()
+	}
+
+	return domain.HealthStatusOK
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/sirupsen/logrus"
+)
+
+type Idempotency interface {
+	Reserve(ctx context.Context, userId int, key, fingerprint string,
+		lease time.Duration) (*domain.IdempotencyRecord, error)
+	Complete(ctx context.Context, userId int, key string, response domain.StoredResponse, ttl time.Duration) error
+	Release(ctx context.Context, userId int, key string) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// IdempotencyService keeps completed responses for ttl. A request in
+// progress only holds its key for lease, in case it never completes.
+type IdempotencyService struct {
+	repo  Idempotency
+	ttl   time.Duration
+	lease time.Duration
+}
+
+func NewIdempotencyService(repo Idempotency, ttl, lease time.Duration) *IdempotencyService {
+	return &IdempotencyService{repo: repo, ttl: ttl, lease: lease}
+}
+
+// Begin claims the key for a request with the given fingerprint. It returns
+// the stored response when the request was already handled, and nil when
+// the caller should handle it and then call Complete or Release.
+func (s *IdempotencyService) Begin(ctx context.Context, userId int, key,
+	fingerprint string) (*domain.StoredResponse, error) {
+	ctx, span := tracer.Start(ctx, "IdempotencyService.Begin")
+	defer span.End()
+
+	if key == "" || len(key) > domain.MaxIdempotencyKeyLength {
+		return nil, domain.ErrInvalidIdempotencyKey
+	}
+
+	record, err := s.repo.Reserve(ctx, userId, key, fingerprint, s.lease)
+	if err != nil || record == nil {
+		return nil, err
+	}
+
+	if record.Fingerprint != fingerprint {
+		return nil, domain.ErrIdempotencyKeyReused
+	}
+
+	if record.Response == nil {
+		return nil, domain.ErrRequestInProgress
+	}
+
+	return record.Response, nil
+}
+
+func (s *IdempotencyService) Complete(ctx context.Context, userId int, key string,
+	response domain.StoredResponse) error {
+	ctx, span := tracer.Start(ctx, "IdempotencyService.Complete")
+	defer span.End()
+
+	return s.repo.Complete(ctx, userId, key, response, s.ttl)
+}
+
+// Release frees a key whose request failed, so the client can retry it.
+func (s *IdempotencyService) Release(ctx context.Context, userId int, key string) error {
+	ctx, span := tracer.Start(ctx, "IdempotencyService.Release")
+	defer span.End()
+
+	return s.repo.Release(ctx, userId, key)
+}
+
+// RunCleanup deletes expired idempotency keys every interval until ctx is cancelled.
+func (s *IdempotencyService) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.repo.DeleteExpired(ctx)
+			if err != nil {
+				logrus.WithField("job", "idempotency_cleanup").Error(err)
+				continue
+			}
+
+			if deleted > 0 {
+				logrus.WithFields(logrus.Fields{
+					"job":     "idempotency_cleanup",
+					"deleted": deleted,
+				}).Info()
+			}
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"github.com/SavelyDev/crud-app/internal/domain"
+)
+
+type Notification interface {
+	GetNotifications(ctx context.Context, userId int, page domain.Pagination) ([]domain.Notification, error)
+	MarkRead(ctx context.Context, userId, notificationId int) error
+}
+
+type NotificationService struct {
+	repo Notification
+}
+
+func NewNotificationService(repo Notification) *NotificationService {
+	return &NotificationService{repo: repo}
+}
+
+func (s *NotificationService) GetNotifications(ctx context.Context, userId int,
+	page domain.Pagination) ([]domain.Notification, error) {
+	ctx, span := tracer.Start(ctx, "NotificationService.GetNotifications")
+	defer span.End()
+
+	page.Normalize()
+	return s.repo.GetNotifications(ctx, userId, page)
+}
+
+func (s *NotificationService) MarkRead(ctx context.Context, userId, notificationId int) error {
+	ctx, span := tracer.Start(ctx, "NotificationService.MarkRead")
+	defer span.End()
+
+	return s.repo.MarkRead(ctx, userId, notificationId)
+}
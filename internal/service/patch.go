@@ -0,0 +1,66 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+// patchAttempts bounds how often a patch sent without If-Match is applied
+// again after other writes landed between reading and saving the entity.
+const patchAttempts = 3
+
+// retryPatch runs apply, which reads the entity, patches it and saves it
+// against the version it read, again on a version mismatch unless the
+// client made the version a precondition.
+func retryPatch(patch domain.Patch, apply func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := apply()
+		if patch.Version != nil || !errors.Is(err, domain.ErrVersionMismatch) {
+			return err
+		}
+
+		if attempt == patchAttempts {
+			return domain.ErrPatchConflict
+		}
+	}
+}
+
+// applyPatch applies the patch to the JSON form of doc and decodes the
+// result into dst, rejecting fields dst doesn't have.
+func applyPatch(doc interface{}, patch domain.Patch, dst interface{}) error {
+	original, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	var patched []byte
+	switch patch.ContentType {
+	case domain.MergePatchContentType:
+		patched, err = jsonpatch.MergePatch(original, patch.Body)
+	case domain.JSONPatchContentType:
+		var ops jsonpatch.Patch
+		ops, err = jsonpatch.DecodePatch(patch.Body)
+		if err == nil {
+			patched, err = ops.Apply(original)
+		}
+	default:
+		return domain.ErrUnsupportedPatchType
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInvalidPatch, err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(patched))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		return fmt.Errorf("%w: %s", domain.ErrInvalidPatch, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+)
+
+func TestApplyPatch(t *testing.T) {
+	description := "weekly"
+	current := domain.ReplaceItemInput{Title: "groceries", Description: &description, Done: false}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        domain.ReplaceItemInput
+		wantErr     error
+	}{
+		{
+			name:        "merge patch sets a field",
+			contentType: domain.MergePatchContentType,
+			body:        `{"done": true}`,
+			want:        domain.ReplaceItemInput{Title: "groceries", Description: &description, Done: true},
+		},
+		{
+			name:        "merge patch null clears a field",
+			contentType: domain.MergePatchContentType,
+			body:        `{"description": null}`,
+			want:        domain.ReplaceItemInput{Title: "groceries"},
+		},
+		{
+			name:        "empty merge patch keeps everything",
+			contentType: domain.MergePatchContentType,
+			body:        `{}`,
+			want:        current,
+		},
+		{
+			name:        "json patch replaces a field",
+			contentType: domain.JSONPatchContentType,
+			body:        `[{"op": "replace", "path": "/title", "value": "bakery"}]`,
+			want:        domain.ReplaceItemInput{Title: "bakery", Description: &description},
+		},
+		{
+			name:        "json patch test op passes",
+			contentType: domain.JSONPatchContentType,
+			body:        `[{"op": "test", "path": "/done", "value": false}, {"op": "replace", "path": "/done", "value": true}]`,
+			want:        domain.ReplaceItemInput{Title: "groceries", Description: &description, Done: true},
+		},
+		{
+			name:        "json patch test op fails",
+			contentType: domain.JSONPatchContentType,
+			body:        `[{"op": "test", "path": "/done", "value": true}]`,
+			wantErr:     domain.ErrInvalidPatch,
+		},
+		{
+			name:        "json patch on a missing path",
+			contentType: domain.JSONPatchContentType,
+			body:        `[{"op": "replace", "path": "/due", "value": "tomorrow"}]`,
+			wantErr:     domain.ErrInvalidPatch,
+		},
+		{
+			name:        "malformed json patch",
+			contentType: domain.JSONPatchContentType,
+			body:        `{"op": "replace"}`,
+			wantErr:     domain.ErrInvalidPatch,
+		},
+		{
+			name:        "unknown field",
+			contentType: domain.MergePatchContentType,
+			body:        `{"version": 7}`,
+			wantErr:     domain.ErrInvalidPatch,
+		},
+		{
+			name:        "wrong field type",
+			contentType: domain.MergePatchContentType,
+			body:        `{"done": "yes"}`,
+			wantErr:     domain.ErrInvalidPatch,
+		},
+		{
+			name:        "unsupported content type",
+			contentType: "application/json",
+			body:        `{"done": true}`,
+			wantErr:     domain.ErrUnsupportedPatchType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch := domain.Patch{ContentType: tt.contentType, Body: []byte(tt.body)}
+
+			var got domain.ReplaceItemInput
+			err := applyPatch(current, patch, &got)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				return
+			}
+
+			if got.Title != tt.want.Title || got.Done != tt.want.Done ||
+				(got.Description == nil) != (tt.want.Description == nil) ||
+				(got.Description != nil && *got.Description != *tt.want.Description) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPatch(t *testing.T) {
+	errDatabase := errors.New("connection reset")
+
+	tests := []struct {
+		name      string
+		version   domain.VersionMatch
+		results   []error
+		wantErr   error
+		wantCalls int
+	}{
+		{name: "saved at once", results: []error{nil}, wantCalls: 1},
+		{
+			name:      "retried after a concurrent write",
+			results:   []error{domain.ErrVersionMismatch, nil},
+			wantCalls: 2,
+		},
+		{
+			name:      "gives up after patchAttempts",
+			results:   []error{domain.ErrVersionMismatch, domain.ErrVersionMismatch, domain.ErrVersionMismatch},
+			wantErr:   domain.ErrPatchConflict,
+			wantCalls: patchAttempts,
+		},
+		{
+			name:      "If-Match is not retried",
+			version:   domain.VersionMatch{3},
+			results:   []error{domain.ErrVersionMismatch},
+			wantErr:   domain.ErrVersionMismatch,
+			wantCalls: 1,
+		},
+		{
+			name:      "other errors are not retried",
+			results:   []error{errDatabase},
+			wantErr:   errDatabase,
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := retryPatch(domain.Patch{Version: tt.version}, func() error {
+				calls++
+				return tt.results[calls-1]
+			})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+
+			if calls != tt.wantCalls {
+				t.Errorf("apply ran %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/sirupsen/logrus"
+)
+
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit domain.RateLimit) (bool, float64, error)
+	DeleteIdle(ctx context.Context, idle time.Duration) (int64, error)
+}
+
+type RateLimitService struct {
+	store  RateLimitStore
+	limits map[string]domain.RateLimit
+}
+
+// NewRateLimitService limits each route group in limits, other groups
+// aren't limited.
+func NewRateLimitService(store RateLimitStore, limits map[string]domain.RateLimit) *RateLimitService {
+	valid := make(map[string]domain.RateLimit, len(limits))
+	for group, limit := range limits {
+		if limit.Limit <= 0 || limit.Period <= 0 {
+			continue
+		}
+
+		if limit.Burst < 1 {
+			limit.Burst = 1
+		}
+
+		valid[group] = limit
+	}
+
+	return &RateLimitService{store: store, limits: valid}
+}
+
+// Take spends one of the client's requests in the group. It returns false
+// when the group isn't limited.
+func (s *RateLimitService) Take(ctx context.Context, group, client string) (domain.RateLimitResult, bool, error) {
+	ctx, span := tracer.Start(ctx, "RateLimitService.Take")
+	defer span.End()
+
+	limit, ok := s.limits[group]
+	if !ok {
+		return domain.RateLimitResult{}, false, nil
+	}
+
+	allowed, tokens, err := s.store.Take(ctx, group+":"+client, limit)
+	if err != nil {
+		return domain.RateLimitResult{}, true, err
+	}
+
+	result := domain.RateLimitResult{
+		Allowed:    allowed,
+		Limit:      limit.Burst,
+		Remaining:  int(math.Max(0, math.Floor(tokens))),
+		ResetAfter: seconds((float64(limit.Burst) - tokens) / limit.Rate()),
+	}
+
+	if !allowed {
+		result.RetryAfter = seconds((1 - tokens) / limit.Rate())
+	}
+
+	return result, true, nil
+}
+
+// RunCleanup deletes the buckets that are full again every interval until
+// ctx is cancelled.
+func (s *RateLimitService) RunCleanup(ctx context.Context, interval time.Duration) {
+	var idle time.Duration
+	for _, limit := range s.limits {
+		idle = max(idle, limit.FullAfter())
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := s.store.DeleteIdle(ctx, idle)
+			if err != nil {
+				logrus.WithField("job", "rate_limit_cleanup").Error(err)
+				continue
+			}
+
+			if deleted > 0 {
+				logrus.WithFields(logrus.Fields{
+					"job":     "rate_limit_cleanup",
+					"deleted": deleted,
+				}).Info()
+			}
+		}
+	}
+}
+
+// seconds rounds up to whole seconds, as sent in headers.
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s)) * time.Second
+}
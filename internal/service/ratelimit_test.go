@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+)
+
+type fakeRateLimitStore struct {
+	allowed bool
+	tokens  float64
+	key     string
+}
+
+func (f *fakeRateLimitStore) Take(ctx context.Context, key string, limit domain.RateLimit) (bool, float64, error) {
+	f.key = key
+	return f.allowed, f.tokens, nil
+}
+
+func (f *fakeRateLimitStore) DeleteIdle(ctx context.Context, idle time.Duration) (int64, error) {
+	return 0, nil
+}
+
+func TestRateLimitServiceTake(t *testing.T) {
+	// One request every 2 seconds, in bursts of up to 10.
+	limits := map[string]domain.RateLimit{"api": {Limit: 30, Period: time.Minute, Burst: 10}}
+
+	tests := []struct {
+		name    string
+		allowed bool
+		tokens  float64
+		want    domain.RateLimitResult
+	}{
+		{
+			name:    "full bucket",
+			allowed: true,
+			tokens:  9,
+			want:    domain.RateLimitResult{Allowed: true, Limit: 10, Remaining: 9, ResetAfter: 2 * time.Second},
+		},
+		{
+			name:    "partial tokens round down and reset rounds up",
+			allowed: true,
+			tokens:  2.5,
+			want:    domain.RateLimitResult{Allowed: true, Limit: 10, Remaining: 2, ResetAfter: 15 * time.Second},
+		},
+		{
+			name:   "empty bucket",
+			tokens: 0.25,
+			want: domain.RateLimitResult{Limit: 10, Remaining: 0, RetryAfter: 2 * time.Second,
+				ResetAfter: 20 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeRateLimitStore{allowed: tt.allowed, tokens: tt.tokens}
+
+			got, limited, err := NewRateLimitService(store, limits).Take(context.Background(), "api", "user:1")
+			if err != nil || !limited {
+				t.Fatalf("got limited %v, %v, want a limited group", limited, err)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+
+			if store.key != "api:user:1" {
+				t.Errorf("took from bucket %q, want api:user:1", store.key)
+			}
+		})
+	}
+}
+
+func TestNewRateLimitService(t *testing.T) {
+	s := NewRateLimitService(&fakeRateLimitStore{allowed: true}, map[string]domain.RateLimit{
+		"api":      {Limit: 10, Period: time.Minute},
+		"disabled": {Limit: 0, Period: time.Minute, Burst: 5},
+		"invalid":  {Limit: 10, Burst: 5},
+	})
+
+	if got := s.limits["api"].Burst; got != 1 {
+		t.Errorf("got a burst of %d, want a missing burst to default to 1", got)
+	}
+
+	for _, group := range []string{"disabled", "invalid", "other"} {
+		if _, limited, _ := s.Take(context.Background(), group, "user:1"); limited {
+			t.Errorf("group %q is limited, want it unlimited", group)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"github.com/SavelyDev/crud-app/internal/domain"
+)
+
+type Search interface {
+	Search(ctx context.Context, userId int, input domain.SearchInput) ([]domain.SearchHit, error)
+}
+
+type SearchService struct {
+	repo Search
+}
+
+func NewSearchService(repo Search) *SearchService {
+	return &SearchService{repo: repo}
+}
+
+func (s *SearchService) Search(ctx context.Context, userId int, input domain.SearchInput) ([]domain.SearchHit, error) {
+	ctx, span := tracer.Start(ctx, "SearchService.Search")
+	defer span.End()
+
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.repo.Search(ctx, userId, input)
+}
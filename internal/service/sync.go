@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+)
+
+type Sync interface {
+	GetHorizon(ctx context.Context) (domain.SyncCursor, error)
+	GetChanges(ctx context.Context, userId int, after, horizon domain.SyncCursor, withDeleted bool,
+		limit int) ([]domain.SyncRecord, error)
+}
+
+type SyncService struct {
+	repo     Sync
+	listRepo TodoList
+	itemRepo TodoItem
+	// tokenTTL is how long tombstones are kept, older tokens can't be resumed.
+	tokenTTL time.Duration
+}
+
+func NewSyncService(repo Sync, listRepo TodoList, itemRepo TodoItem, tokenTTL time.Duration) *SyncService {
+	return &SyncService{repo: repo, listRepo: listRepo, itemRepo: itemRepo, tokenTTL: tokenTTL}
+}
+
+// GetChanges returns what changed since the token, or everything the user
+// can access when the token is empty.
+func (s *SyncService) GetChanges(ctx context.Context, userId int, since string, limit int) (domain.SyncChanges, error) {
+	ctx, span := tracer.Start(ctx, "SyncService.GetChanges")
+	defer span.End()
+
+	var changes domain.SyncChanges
+
+	if limit <= 0 {
+		limit = domain.DefaultSyncLimit
+	}
+
+	if limit > domain.MaxSyncLimit {
+		limit = domain.MaxSyncLimit
+	}
+
+	after := domain.SyncCursor{IssuedAt: time.Now()}
+	if since != "" {
+		var err error
+		if after, err = domain.ParseSyncToken(since); err != nil {
+			return changes, err
+		}
+
+		if s.tokenTTL > 0 && time.Since(after.IssuedAt) > s.tokenTTL {
+			return changes, domain.ErrSyncTokenExpired
+		}
+	}
+
+	horizon, err := s.repo.GetHorizon(ctx)
+	if err != nil {
+		return changes, err
+	}
+
+	records, err := s.repo.GetChanges(ctx, userId, after, horizon, since != "", limit+1)
+	if err != nil {
+		return changes, err
+	}
+
+	next := horizon
+	next.IssuedAt = time.Now()
+
+	if len(records) > limit {
+		records = records[:limit]
+		changes.HasMore = true
+
+		// The client is still catching up, deletions are kept as long
+		// as they were for the token it started from.
+		next = records[limit-1].Cursor
+		next.IssuedAt = after.IssuedAt
+	}
+
+	changes.Changes = records
+	changes.Token = next.Token()
+
+	if changes.Changes == nil {
+		changes.Changes = []domain.SyncRecord{}
+	}
+
+	return changes, nil
+}
+
+// ApplyChanges applies the client's changes in order, each on its own.
+// Updates and deletes carrying a base version conflict when the record has
+// changed on the server since.
+func (s *SyncService) ApplyChanges(ctx context.Context, userId int,
+	input domain.SyncInput) ([]domain.SyncResult, error) {
+	ctx, span := tracer.Start(ctx, "SyncService.ApplyChanges")
+	defer span.End()
+
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	createdLists := make(map[string]int)
+	results := make([]domain.SyncResult, len(input.Changes))
+
+	for idx, change := range input.Changes {
+		if change.EntityType == domain.EntityTypeList {
+			results[idx] = s.applyListChange(ctx, userId, change, createdLists)
+		} else {
+			results[idx] = s.applyItemChange(ctx, userId, change, createdLists)
+		}
+	}
+
+	return results, nil
+}
+
+func (s *SyncService) applyListChange(ctx context.Context, userId int, change domain.ClientChange,
+	createdLists map[string]int) domain.SyncResult {
+	result := domain.SyncResult{ClientId: change.ClientId, EntityType: change.EntityType, Id: change.Id}
+
+	var err error
+	switch {
+	case change.Op == domain.SyncOpDelete:
+		err = s.listRepo.DeleteList(ctx, userId, change.Id, domain.MatchVersion(change.BaseVersion))
+		if errors.Is(err, sql.ErrNoRows) {
+			err = nil
+		}
+	case change.Id == 0:
+		result.Id, err = s.listRepo.CreateList(ctx, userId, domain.TodoList{
+			Title:       change.Title,
+			Description: change.Description,
+			IsTemplate:  change.IsTemplate,
+		})
+		if err == nil && change.ClientId != "" {
+			createdLists[change.ClientId] = result.Id
+		}
+	default:
+		err = s.listRepo.ReplaceList(ctx, userId, change.Id, domain.ReplaceListInput{
+			Title:       change.Title,
+			Description: change.Description,
+			IsTemplate:  change.IsTemplate,
+			Version:     domain.MatchVersion(change.BaseVersion),
+		})
+	}
+
+	current, currentErr := s.listRepo.GetListById(ctx, userId, result.Id)
+	record := &domain.SyncRecord{EntityType: domain.EntityTypeList, Id: result.Id, List: &current}
+	if currentErr != nil {
+		record = &domain.SyncRecord{EntityType: domain.EntityTypeList, Id: result.Id, Deleted: true}
+	}
+
+	return syncResult(result, err, record)
+}
+
+func (s *SyncService) applyItemChange(ctx context.Context, userId int, change domain.ClientChange,
+	createdLists map[string]int) domain.SyncResult {
+	result := domain.SyncResult{ClientId: change.ClientId, EntityType: change.EntityType, Id: change.Id}
+
+	listId := change.ListId
+	if change.ListClientId != "" {
+		var ok bool
+		if listId, ok = createdLists[change.ListClientId]; !ok {
+			result.Status = domain.SyncStatusError
+			result.Error = "unknown list_client_id"
+			return result
+		}
+	}
+
+	var err error
+	switch {
+	case change.Op == domain.SyncOpDelete:
+		err = s.itemRepo.DeleteItem(ctx, userId, change.Id, domain.MatchVersion(change.BaseVersion))
+		if errors.Is(err, sql.ErrNoRows) {
+			err = nil
+		}
+	case change.Id == 0:
+		if _, err = s.listRepo.GetListById(ctx, userId, listId); err != nil {
+			result.Status = domain.SyncStatusError
+			result.Error = "list not found"
+			return result
+		}
+
+		result.Id, err = s.itemRepo.CreateItem(ctx, userId, listId, domain.TodoItem{
+			Title:       change.Title,
+			Description: change.Description,
+			Done:        change.Done,
+		})
+	default:
+		err = s.itemRepo.ReplaceItem(ctx, userId, change.Id, domain.ReplaceItemInput{
+			Title:       change.Title,
+			Description: change.Description,
+			Done:        change.Done,
+			Version:     domain.MatchVersion(change.BaseVersion),
+		})
+
+		if err == nil && listId != 0 {
+			if _, err = s.listRepo.GetListById(ctx, userId, listId); err == nil {
+				err = s.itemRepo.MoveItem(ctx, userId, change.Id, listId)
+			}
+		}
+	}
+
+	current, currentErr := s.itemRepo.GetItemById(ctx, userId, result.Id)
+	record := &domain.SyncRecord{EntityType: domain.EntityTypeItem, Id: result.Id, Item: &current}
+	if currentErr != nil {
+		record = &domain.SyncRecord{EntityType: domain.EntityTypeItem, Id: result.Id, Deleted: true}
+	}
+
+	return syncResult(result, err, record)
+}
+
+// syncResult completes the result of a change given the record's state on
+// the server after applying it.
+func syncResult(result domain.SyncResult, err error, current *domain.SyncRecord) domain.SyncResult {
+	switch {
+	case err == nil:
+		result.Status = domain.SyncStatusApplied
+		if current.List != nil {
+			result.Version = current.List.Version
+		}
+		if current.Item != nil {
+			result.Version = current.Item.Version
+		}
+	case errors.Is(err, domain.ErrVersionMismatch), errors.Is(err, sql.ErrNoRows):
+		result.Status = domain.SyncStatusConflict
+		result.Current = current
+	default:
+		result.Status = domain.SyncStatusError
+		result.Error = err.Error()
+	}
+
+	return result
+}
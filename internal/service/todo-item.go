@@ -1,15 +1,24 @@
 package service
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/SavelyDev/crud-app/internal/domain"
 )
 
 type TodoItem interface {
-	CreateItem(listId int, input domain.TodoItem) (int, error)
-	GetAllItems(userId, listId int) ([]domain.TodoItem, error)
-	GetItemById(userId, itemId int) (domain.TodoItem, error)
-	DeleteItem(userId, itemId int) error
-	UpdateItem(userId, itemId int, input domain.UpdateItemInput) error
+	CreateItem(ctx context.Context, userId, listId int, input domain.TodoItem) (int, error)
+	GetAllItems(ctx context.Context, userId, listId int) ([]domain.TodoItem, error)
+	GetItemById(ctx context.Context, userId, itemId int) (domain.TodoItem, error)
+	GetItemsByLists(ctx context.Context, userId int, listIds []int) (map[int][]domain.TodoItem, error)
+	DeleteItem(ctx context.Context, userId, itemId int, version domain.VersionMatch) error
+	UpdateItem(ctx context.Context, userId, itemId int, input domain.UpdateItemInput) error
+	ReplaceItem(ctx context.Context, userId, itemId int, input domain.ReplaceItemInput) error
+	MoveItem(ctx context.Context, userId, itemId, listId int) error
+	MoveItems(ctx context.Context, userId int, itemIds []int, listId int) error
+	CopyItem(ctx context.Context, userId, itemId, listId int) (int, error)
+	ApplyBulk(ctx context.Context, userId int, input domain.BulkInput) ([]domain.BulkResult, error)
 }
 
 type TodoItemService struct {
@@ -21,27 +30,150 @@ func NewTodoItemService(repo TodoItem, listRepo TodoList) *TodoItemService {
 	return &TodoItemService{repo: repo, listRepo: listRepo}
 }
 
-func (s *TodoItemService) CreateItem(userId, listId int, input domain.TodoItem) (int, error) {
-	_, err := s.listRepo.GetListById(userId, listId)
+func (s *TodoItemService) CreateItem(ctx context.Context, userId, listId int, input domain.TodoItem) (int, error) {
+	ctx, span := tracer.Start(ctx, "TodoItemService.CreateItem")
+	defer span.End()
+
+	_, err := s.listRepo.GetListById(ctx, userId, listId)
 	if err != nil {
 		return 0, err
 	}
 
-	return s.repo.CreateItem(listId, input)
+	return s.repo.CreateItem(ctx, userId, listId, input)
+}
+
+func (s *TodoItemService) GetAllItems(ctx context.Context, userId, listId int) ([]domain.TodoItem, error) {
+	ctx, span := tracer.Start(ctx, "TodoItemService.GetAllItems")
+	defer span.End()
+
+	return s.repo.GetAllItems(ctx, userId, listId)
+}
+
+func (s *TodoItemService) GetItemById(ctx context.Context, userId, itemId int) (domain.TodoItem, error) {
+	ctx, span := tracer.Start(ctx, "TodoItemService.GetItemById")
+	defer span.End()
+
+	return s.repo.GetItemById(ctx, userId, itemId)
+}
+
+func (s *TodoItemService) GetItemsByLists(ctx context.Context, userId int,
+	listIds []int) (map[int][]domain.TodoItem, error) {
+	ctx, span := tracer.Start(ctx, "TodoItemService.GetItemsByLists")
+	defer span.End()
+
+	return s.repo.GetItemsByLists(ctx, userId, listIds)
+}
+
+func (s *TodoItemService) DeleteItem(ctx context.Context, userId, itemId int) error {
+	ctx, span := tracer.Start(ctx, "TodoItemService.DeleteItem")
+	defer span.End()
+
+	return s.repo.DeleteItem(ctx, userId, itemId, nil)
 }
 
-func (s *TodoItemService) GetAllItems(userId, listId int) ([]domain.TodoItem, error) {
-	return s.repo.GetAllItems(userId, listId)
+func (s *TodoItemService) UpdateItem(ctx context.Context, userId, itemId int, input domain.UpdateItemInput) error {
+	ctx, span := tracer.Start(ctx, "TodoItemService.UpdateItem")
+	defer span.End()
+
+	return s.repo.UpdateItem(ctx, userId, itemId, input)
 }
 
-func (s *TodoItemService) GetItemById(userId, itemId int) (domain.TodoItem, error) {
-	return s.repo.GetItemById(userId, itemId)
+func (s *TodoItemService) ReplaceItem(ctx context.Context, userId, itemId int, input domain.ReplaceItemInput) error {
+	ctx, span := tracer.Start(ctx, "TodoItemService.ReplaceItem")
+	defer span.End()
+
+	if err := input.Validate(); err != nil {
+		return err
+	}
+	return s.repo.ReplaceItem(ctx, userId, itemId, input)
 }
 
-func (s *TodoItemService) DeleteItem(userId, itemId int) error {
-	return s.repo.DeleteItem(userId, itemId)
+// PatchItem applies the patch to the item's writable fields, see PatchList.
+func (s *TodoItemService) PatchItem(ctx context.Context, userId, itemId int, patch domain.Patch) error {
+	ctx, span := tracer.Start(ctx, "TodoItemService.PatchItem")
+	defer span.End()
+
+	if err := patch.Validate(); err != nil {
+		return err
+	}
+
+	return retryPatch(patch, func() error {
+		item, err := s.repo.GetItemById(ctx, userId, itemId)
+		if err != nil {
+			return err
+		}
+
+		if !patch.Version.Matches(item.Version) {
+			return domain.ErrVersionMismatch
+		}
+
+		current := domain.ReplaceItemInput{Title: item.Title, Description: item.Description, Done: item.Done}
+
+		var input domain.ReplaceItemInput
+		if err := applyPatch(current, patch, &input); err != nil {
+			return err
+		}
+
+		if err := input.Validate(); err != nil {
+			return fmt.Errorf("%w: %s", domain.ErrInvalidPatch, err)
+		}
+
+		input.Version = domain.VersionMatch{item.Version}
+
+		return s.repo.ReplaceItem(ctx, userId, itemId, input)
+	})
 }
 
-func (s *TodoItemService) UpdateItem(userId, itemId int, input domain.UpdateItemInput) error {
-	return s.repo.UpdateItem(userId, itemId, input)
+func (s *TodoItemService) MoveItem(ctx context.Context, userId, itemId int, input domain.MoveItemInput) error {
+	ctx, span := tracer.Start(ctx, "TodoItemService.MoveItem")
+	defer span.End()
+
+	_, err := s.listRepo.GetListById(ctx, userId, input.ListId)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.MoveItem(ctx, userId, itemId, input.ListId)
+}
+
+func (s *TodoItemService) MoveItems(ctx context.Context, userId int, input domain.MoveItemsInput) error {
+	ctx, span := tracer.Start(ctx, "TodoItemService.MoveItems")
+	defer span.End()
+
+	if err := input.Validate(); err != nil {
+		return err
+	}
+
+	_, err := s.listRepo.GetListById(ctx, userId, input.ListId)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.MoveItems(ctx, userId, input.ItemIds, input.ListId)
+}
+
+func (s *TodoItemService) CopyItem(ctx context.Context, userId, itemId int, input domain.MoveItemInput) (int, error) {
+	ctx, span := tracer.Start(ctx, "TodoItemService.CopyItem")
+	defer span.End()
+
+	_, err := s.listRepo.GetListById(ctx, userId, input.ListId)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.repo.CopyItem(ctx, userId, itemId, input.ListId)
+}
+
+func (s *TodoItemService) ApplyBulk(ctx context.Context, userId int,
+	input domain.BulkInput) (domain.BulkResponse, error) {
+	ctx, span := tracer.Start(ctx, "TodoItemService.ApplyBulk")
+	defer span.End()
+
+	if err := input.Validate(); err != nil {
+		return domain.BulkResponse{}, err
+	}
+
+	results, err := s.repo.ApplyBulk(ctx, userId, input)
+
+	return domain.BulkResponse{Mode: input.Mode, Results: results}, err
 }
@@ -1,44 +1,237 @@
 package service
 
 import (
+	"context"
+	"fmt"
+	"strings"
+
 	"github.com/SavelyDev/crud-app/internal/domain"
 )
 
 type TodoList interface {
-	CreateList(userId int, todoList domain.TodoList) (int, error)
-	GetAllLists(userId int) ([]domain.TodoList, error)
-	GetListById(userId, listId int) (domain.TodoList, error)
-	DeleteList(userId, listId int) error
-	UpdateList(userId, listId int, input domain.UpdateListInput) error
+	CreateList(ctx context.Context, userId int, todoList domain.TodoList) (int, error)
+	GetAllLists(ctx context.Context, userId int, withArchived bool) ([]domain.TodoList, error)
+	GetListById(ctx context.Context, userId, listId int) (domain.TodoList, error)
+	GetListsByItems(ctx context.Context, userId int, itemIds []int) (map[int]domain.TodoList, error)
+	DeleteList(ctx context.Context, userId, listId int, version domain.VersionMatch) error
+	SetArchived(ctx context.Context, userId, listId int, archived bool) error
+	UpdateList(ctx context.Context, userId, listId int, input domain.UpdateListInput) error
+	ReplaceList(ctx context.Context, userId, listId int, input domain.ReplaceListInput) error
+	GetTemplates(ctx context.Context, userId int) ([]domain.TodoList, error)
+	CreateListWithItems(ctx context.Context, userId int, todoList domain.TodoList, items []domain.TodoItem) (int, error)
+	AddMember(ctx context.Context, userId, listId int, email string) error
+	GetMembers(ctx context.Context, userId, listId int) ([]domain.ListMember, error)
+	RemoveMember(ctx context.Context, userId, listId, memberId int) error
 }
 
 type TodoListService struct {
-	repo TodoList
+	repo     TodoList
+	itemRepo TodoItem
+}
+
+func NewTodoListService(repo TodoList, itemRepo TodoItem) *TodoListService {
+	return &TodoListService{repo: repo, itemRepo: itemRepo}
+}
+
+func (s *TodoListService) CreateList(ctx context.Context, userId int, todoList domain.TodoList) (int, error) {
+	ctx, span := tracer.Start(ctx, "TodoListService.CreateList")
+	defer span.End()
+
+	return s.repo.CreateList(ctx, userId, todoList)
+}
+
+func (s *TodoListService) GetAllLists(ctx context.Context, userId int, withArchived bool) ([]domain.TodoList, error) {
+	ctx, span := tracer.Start(ctx, "TodoListService.GetAllLists")
+	defer span.End()
+
+	return s.repo.GetAllLists(ctx, userId, withArchived)
 }
 
-func NewTodoListService(repo TodoList) *TodoListService {
-	return &TodoListService{repo: repo}
+func (s *TodoListService) GetListById(ctx context.Context, userId, listId int) (domain.TodoList, error) {
+	ctx, span := tracer.Start(ctx, "TodoListService.GetListById")
+	defer span.End()
+
+	return s.repo.GetListById(ctx, userId, listId)
 }
 
-func (s *TodoListService) CreateList(userId int, todoList domain.TodoList) (int, error) {
-	return s.repo.CreateList(userId, todoList)
+func (s *TodoListService) GetListsByItems(ctx context.Context, userId int,
+	itemIds []int) (map[int]domain.TodoList, error) {
+	ctx, span := tracer.Start(ctx, "TodoListService.GetListsByItems")
+	defer span.End()
+
+	return s.repo.GetListsByItems(ctx, userId, itemIds)
 }
 
-func (s *TodoListService) GetAllLists(userId int) ([]domain.TodoList, error) {
-	return s.repo.GetAllLists(userId)
+func (s *TodoListService) DeleteList(ctx context.Context, userId, listId int) error {
+	ctx, span := tracer.Start(ctx, "TodoListService.DeleteList")
+	defer span.End()
+
+	return s.repo.DeleteList(ctx, userId, listId, nil)
 }
 
-func (s *TodoListService) GetListById(userId, listId int) (domain.TodoList, error) {
-	return s.repo.GetListById(userId, listId)
+func (s *TodoListService) SetArchived(ctx context.Context, userId, listId int, archived bool) error {
+	ctx, span := tracer.Start(ctx, "TodoListService.SetArchived")
+	defer span.End()
+
+	return s.repo.SetArchived(ctx, userId, listId, archived)
 }
 
-func (s *TodoListService) DeleteList(userId, listId int) error {
-	return s.repo.DeleteList(userId, listId)
+func (s *TodoListService) UpdateList(ctx context.Context, userId, listId int, input domain.UpdateListInput) error {
+	ctx, span := tracer.Start(ctx, "TodoListService.UpdateList")
+	defer span.End()
+
+	if err := input.Validate(); err != nil {
+		return err
+	}
+	return s.repo.UpdateList(ctx, userId, listId, input)
 }
 
-func (s *TodoListService) UpdateList(userId, listId int, input domain.UpdateListInput) error {
+func (s *TodoListService) ReplaceList(ctx context.Context, userId, listId int, input domain.ReplaceListInput) error {
+	ctx, span := tracer.Start(ctx, "TodoListService.ReplaceList")
+	defer span.End()
+
 	if err := input.Validate(); err != nil {
 		return err
 	}
-	return s.repo.UpdateList(userId, listId, input)
+	return s.repo.ReplaceList(ctx, userId, listId, input)
+}
+
+// PatchList applies the patch to the list's writable fields. The result is
+// saved against the version the patch was applied to, so a concurrent change
+// is never overwritten: it fails the patch with domain.ErrVersionMismatch
+// when the client sent If-Match, and the patch is applied again to the new
+// version otherwise.
+func (s *TodoListService) PatchList(ctx context.Context, userId, listId int, patch domain.Patch) error {
+	ctx, span := tracer.Start(ctx, "TodoListService.PatchList")
+	defer span.End()
+
+	if err := patch.Validate(); err != nil {
+		return err
+	}
+
+	return retryPatch(patch, func() error {
+		list, err := s.repo.GetListById(ctx, userId, listId)
+		if err != nil {
+			return err
+		}
+
+		if !patch.Version.Matches(list.Version) {
+			return domain.ErrVersionMismatch
+		}
+
+		current := domain.ReplaceListInput{Title: list.Title, Description: list.Description, IsTemplate: list.IsTemplate}
+
+		var input domain.ReplaceListInput
+		if err := applyPatch(current, patch, &input); err != nil {
+			return err
+		}
+
+		if err := input.Validate(); err != nil {
+			return fmt.Errorf("%w: %s", domain.ErrInvalidPatch, err)
+		}
+
+		input.Version = domain.VersionMatch{list.Version}
+
+		return s.repo.ReplaceList(ctx, userId, listId, input)
+	})
+}
+
+func (s *TodoListService) GetTemplates(ctx context.Context, userId int) ([]domain.TodoList, error) {
+	ctx, span := tracer.Start(ctx, "TodoListService.GetTemplates")
+	defer span.End()
+
+	return s.repo.GetTemplates(ctx, userId)
+}
+
+func (s *TodoListService) DuplicateList(ctx context.Context, userId, listId int,
+	input domain.DuplicateListInput) (int, error) {
+	ctx, span := tracer.Start(ctx, "TodoListService.DuplicateList")
+	defer span.End()
+
+	list, items, err := s.getListWithItems(ctx, userId, listId)
+	if err != nil {
+		return 0, err
+	}
+
+	if input.Title != nil {
+		list.Title = *input.Title
+	}
+
+	if input.ResetDone {
+		resetDone(items)
+	}
+
+	return s.repo.CreateListWithItems(ctx, userId, list, items)
+}
+
+func (s *TodoListService) CreateFromTemplate(ctx context.Context, userId, templateId int,
+	input domain.FromTemplateInput) (int, error) {
+	ctx, span := tracer.Start(ctx, "TodoListService.CreateFromTemplate")
+	defer span.End()
+
+	list, items, err := s.getListWithItems(ctx, userId, templateId)
+	if err != nil {
+		return 0, err
+	}
+
+	if !list.IsTemplate {
+		return 0, domain.ErrNotTemplate
+	}
+
+	list.Title = input.Substitute(list.Title)
+	list.IsTemplate = false
+
+	for i := range items {
+		items[i].Title = input.Substitute(items[i].Title)
+	}
+
+	if input.ResetDone {
+		resetDone(items)
+	}
+
+	return s.repo.CreateListWithItems(ctx, userId, list, items)
+}
+
+// AddMember shares the list with the user registered with email, it
+// succeeds alike for unknown emails.
+func (s *TodoListService) AddMember(ctx context.Context, userId, listId int, input domain.AddMemberInput) error {
+	ctx, span := tracer.Start(ctx, "TodoListService.AddMember")
+	defer span.End()
+
+	return s.repo.AddMember(ctx, userId, listId, strings.TrimSpace(input.Email))
+}
+
+func (s *TodoListService) GetMembers(ctx context.Context, userId, listId int) ([]domain.ListMember, error) {
+	ctx, span := tracer.Start(ctx, "TodoListService.GetMembers")
+	defer span.End()
+
+	return s.repo.GetMembers(ctx, userId, listId)
+}
+
+func (s *TodoListService) RemoveMember(ctx context.Context, userId, listId, memberId int) error {
+	ctx, span := tracer.Start(ctx, "TodoListService.RemoveMember")
+	defer span.End()
+
+	return s.repo.RemoveMember(ctx, userId, listId, memberId)
+}
+
+func (s *TodoListService) getListWithItems(ctx context.Context, userId,
+	listId int) (domain.TodoList, []domain.TodoItem, error) {
+	list, err := s.repo.GetListById(ctx, userId, listId)
+	if err != nil {
+		return list, nil, err
+	}
+
+	items, err := s.itemRepo.GetAllItems(ctx, userId, listId)
+	if err != nil {
+		return list, nil, err
+	}
+
+	return list, items, nil
+}
+
+func resetDone(items []domain.TodoItem) {
+	for i := range items {
+		items[i].Done = false
+	}
 }
@@ -0,0 +1,7 @@
+package service
+
+import "go.opentelemetry.io/otel"
+
+// tracer starts a span for each service method, under the span of the
+// request that called it.
+var tracer = otel.Tracer("github.com/SavelyDev/crud-app/internal/service")
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/sirupsen/logrus"
+)
+
+type Trash interface {
+	GetTrash(ctx context.Context, userId int) ([]domain.TrashEntry, error)
+	RestoreList(ctx context.Context, userId, listId int) error
+	RestoreItem(ctx context.Context, userId, itemId int) error
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
+}
+
+type TrashService struct {
+	repo      Trash
+	retention time.Duration
+}
+
+func NewTrashService(repo Trash, retention time.Duration) *TrashService {
+	return &TrashService{repo: repo, retention: retention}
+}
+
+func (s *TrashService) GetTrash(ctx context.Context, userId int) ([]domain.TrashEntry, error) {
+	ctx, span := tracer.Start(ctx, "TrashService.GetTrash")
+	defer span.End()
+
+	return s.repo.GetTrash(ctx, userId)
+}
+
+func (s *TrashService) Restore(ctx context.Context, userId int, entityType string, id int) error {
+	ctx, span := tracer.Start(ctx, "TrashService.Restore")
+	defer span.End()
+
+	if err := domain.ValidateTrashType(entityType); err != nil {
+		return err
+	}
+
+	if entityType == domain.TrashTypeList {
+		return s.repo.RestoreList(ctx, userId, id)
+	}
+
+	return s.repo.RestoreItem(ctx, userId, id)
+}
+
+// RunRetention purges trash entries older than the retention period
+// every interval until ctx is cancelled.
+func (s *TrashService) RunRetention(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.repo.Purge(ctx, time.Now().Add(-s.retention))
+			if err != nil {
+				logrus.WithField("job", "trash_retention").Error(err)
+				continue
+			}
+
+			if purged > 0 {
+				logrus.WithFields(logrus.Fields{
+					"job":    "trash_retention",
+					"purged": purged,
+				}).Info()
+			}
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	webhookSignatureHeader = "X-Webhook-Signature"
+	webhookEventHeader     = "X-Webhook-Event"
+	webhookDeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// Delivery errors are stored for the webhook's owner to read, so they never
+// carry the raw error, which can describe the server's own network.
+const (
+	deliveryErrInvalidURL = "invalid url"
+	deliveryErrAddress    = "address not allowed"
+	deliveryErrTimeout    = "request timed out"
+	deliveryErrConnection = "request failed"
+)
+
+type Webhook interface {
+	CreateWebhook(ctx context.Context, userId int, input domain.WebhookInput, secret string) (int, error)
+	GetWebhooks(ctx context.Context, userId int) ([]domain.Webhook, error)
+	GetWebhookById(ctx context.Context, userId, webhookId int) (domain.Webhook, error)
+	UpdateWebhook(ctx context.Context, userId, webhookId int, input domain.WebhookInput) error
+	DeleteWebhook(ctx context.Context, userId, webhookId int) error
+	CreateTestDelivery(ctx context.Context, userId, webhookId int) (int, error)
+	GetDeliveries(ctx context.Context, userId, webhookId int, page domain.Pagination) ([]domain.WebhookDelivery, error)
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.PendingDelivery, error)
+	RecordSuccess(ctx context.Context, delivery domain.PendingDelivery, result domain.DeliveryResult) error
+	RecordFailure(ctx context.Context, delivery domain.PendingDelivery, result domain.DeliveryResult,
+		retryIn time.Duration, giveUp bool, disableAfter int) error
+}
+
+type ChangeEvents interface {
+	GetChangeEvent(ctx context.Context, eventId int) (domain.ChangeEvent, error)
+}
+
+type WebhookConfig struct {
+	Timeout      time.Duration
+	BatchSize    int
+	MaxAttempts  int
+	BackoffBase  time.Duration
+	BackoffMax   time.Duration
+	DisableAfter int
+}
+
+type WebhookService struct {
+	repo     Webhook
+	listRepo TodoList
+	events   ChangeEvents
+	client   *http.Client
+	cfg      WebhookConfig
+}
+
+func NewWebhookService(repo Webhook, listRepo TodoList, events ChangeEvents, cfg WebhookConfig) *WebhookService {
+	dialer := &net.Dialer{Timeout: cfg.Timeout, Control: checkWebhookAddress}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would be dialled instead of the webhook, skipping the check.
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   cfg.Timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	return &WebhookService{repo: repo, listRepo: listRepo, events: events, client: client, cfg: cfg}
+}
+
+// CreateWebhook returns the new webhook's id and the secret its payloads are signed with.
+func (s *WebhookService) CreateWebhook(ctx context.Context, userId int,
+	input domain.WebhookInput) (int, string, error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.CreateWebhook")
+	defer span.End()
+
+	if err := s.validate(ctx, userId, input); err != nil {
+		return 0, "", err
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return 0, "", err
+	}
+
+	webhookId, err := s.repo.CreateWebhook(ctx, userId, input, secret)
+
+	return webhookId, secret, err
+}
+
+func (s *WebhookService) GetWebhooks(ctx context.Context, userId int) ([]domain.Webhook, error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.GetWebhooks")
+	defer span.End()
+
+	return s.repo.GetWebhooks(ctx, userId)
+}
+
+func (s *WebhookService) GetWebhookById(ctx context.Context, userId, webhookId int) (domain.Webhook, error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.GetWebhookById")
+	defer span.End()
+
+	return s.repo.GetWebhookById(ctx, userId, webhookId)
+}
+
+func (s *WebhookService) UpdateWebhook(ctx context.Context, userId, webhookId int, input domain.WebhookInput) error {
+	ctx, span := tracer.Start(ctx, "WebhookService.UpdateWebhook")
+	defer span.End()
+
+	if err := s.validate(ctx, userId, input); err != nil {
+		return err
+	}
+
+	return s.repo.UpdateWebhook(ctx, userId, webhookId, input)
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, userId, webhookId int) error {
+	ctx, span := tracer.Start(ctx, "WebhookService.DeleteWebhook")
+	defer span.End()
+
+	return s.repo.DeleteWebhook(ctx, userId, webhookId)
+}
+
+// SendTestEvent queues a ping event, it is sent even if the webhook is disabled.
+func (s *WebhookService) SendTestEvent(ctx context.Context, userId, webhookId int) (int, error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.SendTestEvent")
+	defer span.End()
+
+	return s.repo.CreateTestDelivery(ctx, userId, webhookId)
+}
+
+func (s *WebhookService) GetDeliveries(ctx context.Context, userId, webhookId int,
+	page domain.Pagination) ([]domain.WebhookDelivery, error) {
+	ctx, span := tracer.Start(ctx, "WebhookService.GetDeliveries")
+	defer span.End()
+
+	page.Normalize()
+	return s.repo.GetDeliveries(ctx, userId, webhookId, page)
+}
+
+func (s *WebhookService) validate(ctx context.Context, userId int, input domain.WebhookInput) error {
+	if err := input.Validate(); err != nil {
+		return err
+	}
+
+	if input.ListId != nil {
+		if _, err := s.listRepo.GetListById(ctx, userId, *input.ListId); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RunDeliveries sends due deliveries every interval until ctx is cancelled.
+func (s *WebhookService) RunDeliveries(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.deliverBatch(ctx); err != nil {
+				logrus.WithField("job", "webhook_deliveries").Error(err)
+			}
+		}
+	}
+}
+
+func (s *WebhookService) deliverBatch(ctx context.Context) error {
+	// The lease outlasts every send in the batch, so no other instance picks
+	// the deliveries up before their results are recorded.
+	deliveries, err := s.repo.ClaimDeliveries(ctx, s.cfg.BatchSize, 2*s.cfg.Timeout+time.Minute)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+
+		go func(delivery domain.PendingDelivery) {
+			defer wg.Done()
+
+			if err := s.deliver(ctx, delivery); err != nil {
+				logrus.WithFields(logrus.Fields{
+					"job":         "webhook_deliveries",
+					"delivery_id": delivery.Id,
+				}).Error(err)
+			}
+		}(delivery)
+	}
+	wg.Wait()
+
+	return nil
+}
+
+func (s *WebhookService) deliver(ctx context.Context, delivery domain.PendingDelivery) error {
+	payload := domain.WebhookPayload{DeliveryId: delivery.Id, EventType: delivery.EventType, SentAt: time.Now().UTC()}
+
+	if delivery.EventId != nil {
+		event, err := s.events.GetChangeEvent(ctx, *delivery.EventId)
+		if err != nil {
+			return err
+		}
+
+		payload.Event = &event
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	delivery.Payload = body
+	result := s.send(ctx, delivery)
+
+	if result.Succeeded() {
+		return s.repo.RecordSuccess(ctx, delivery, result)
+	}
+
+	attempts := delivery.Attempts + 1
+
+	return s.repo.RecordFailure(ctx, delivery, result, s.backoff(attempts), attempts >= s.cfg.MaxAttempts,
+		s.cfg.DisableAfter)
+}
+
+func (s *WebhookService) send(ctx context.Context, delivery domain.PendingDelivery) domain.DeliveryResult {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return domain.DeliveryResult{Error: deliveryErrInvalidURL}
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookEventHeader, delivery.EventType)
+	req.Header.Set(webhookDeliveryHeader, strconv.Itoa(delivery.Id))
+	req.Header.Set(webhookSignatureHeader,
+		fmt.Sprintf("t=%s,v1=%s", timestamp, signPayload(delivery.Secret, timestamp, delivery.Payload)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"job":         "webhook_deliveries",
+			"delivery_id": delivery.Id,
+		}).Warn(err)
+
+		return domain.DeliveryResult{Error: deliveryError(err)}
+	}
+	defer resp.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	result := domain.DeliveryResult{ResponseCode: &resp.StatusCode}
+	if !result.Succeeded() {
+		result.Error = fmt.Sprintf("unexpected response status %d", resp.StatusCode)
+	}
+
+	return result
+}
+
+// checkWebhookAddress is the dialer's Control function, it runs after the
+// host is resolved, so a name rebound to an internal address is refused too.
+func checkWebhookAddress(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+
+	if !domain.WebhookAddressAllowed(addrPort.Addr()) {
+		return domain.ErrWebhookAddress
+	}
+
+	return nil
+}
+
+func deliveryError(err error) string {
+	var netErr net.Error
+
+	switch {
+	case errors.Is(err, domain.ErrWebhookAddress):
+		return deliveryErrAddress
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return deliveryErrTimeout
+	default:
+		return deliveryErrConnection
+	}
+}
+
+// backoff returns the delay before the next attempt, doubling with every
+// failed attempt up to the configured maximum.
+func (s *WebhookService) backoff(attempts int) time.Duration {
+	delay := s.cfg.BackoffBase
+	for i := 1; i < attempts && delay < s.cfg.BackoffMax; i++ {
+		delay *= 2
+	}
+
+	if delay > s.cfg.BackoffMax {
+		delay = s.cfg.BackoffMax
+	}
+
+	return delay
+}
+
+// signPayload signs "<timestamp>.<payload>" so receivers can reject both
+// forged and replayed requests.
+func signPayload(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(secret), nil
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/sirupsen/logrus"
+)
+
+func TestWebhookBackoff(t *testing.T) {
+	s := &WebhookService{cfg: WebhookConfig{BackoffBase: time.Minute, BackoffMax: 10 * time.Minute}}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 0, want: time.Minute},
+		{attempts: 1, want: time.Minute},
+		{attempts: 2, want: 2 * time.Minute},
+		{attempts: 3, want: 4 * time.Minute},
+		{attempts: 4, want: 8 * time.Minute},
+		{attempts: 5, want: 10 * time.Minute},
+		{attempts: 100, want: 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := s.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestSignPayload(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		payload   string
+		want      string
+	}{
+		{
+			name:      "empty payload",
+			secret:    "secret",
+			timestamp: "1700000000",
+			want:      "4bc5f74d868b97888288889c5d9d65df02526f94c1592a79fdf4fe8b26e311e5",
+		},
+		{
+			name:      "payload",
+			secret:    "whsec",
+			timestamp: "1700000000",
+			payload:   `{"delivery_id":1}`,
+			want:      "ea824c4b26c1079d760def589870cba7b638f7608ebb23b499fb610ba985d417",
+		},
+		{
+			name:      "timestamp is signed",
+			secret:    "whsec",
+			timestamp: "1700000001",
+			payload:   `{"delivery_id":1}`,
+			want:      "5aa0efacc9a6492af4aace79aa3bbb73e061a2ad80eb175f5c5a564b7c8918af",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := signPayload(tt.secret, tt.timestamp, []byte(tt.payload)); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookSendRefusesInternalAddresses(t *testing.T) {
+	logrus.SetOutput(io.Discard)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	s := NewWebhookService(nil, nil, nil, WebhookConfig{Timeout: time.Second})
+
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{name: "loopback", url: server.URL, want: deliveryErrAddress},
+		{name: "localhost", url: "http://localhost:1/hook", want: deliveryErrAddress},
+		{name: "link-local", url: "http://169.254.169.254/latest/meta-data", want: deliveryErrAddress},
+		{name: "private", url: "http://10.0.0.1/hook", want: deliveryErrAddress},
+		{name: "unspecified", url: "http://[::]:1/hook", want: deliveryErrAddress},
+		{name: "mapped loopback", url: "http://[::ffff:127.0.0.1]:1/hook", want: deliveryErrAddress},
+		{name: "invalid url", url: "http://[::1", want: deliveryErrInvalidURL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := s.send(context.Background(), domain.PendingDelivery{URL: tt.url, Payload: []byte("{}")})
+
+			if result.Error != tt.want || result.ResponseCode != nil {
+				t.Errorf("got %+v, want error %q", result, tt.want)
+			}
+		})
+	}
+
+	if requests != 0 {
+		t.Errorf("the server received %d requests, want none", requests)
+	}
+}
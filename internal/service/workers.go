@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"runtime/debug"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Workers runs the background jobs and keeps track of the ones that are
+// still running, for the readiness check.
+type Workers struct {
+	mu      sync.Mutex
+	running map[string]bool
+	wg      sync.WaitGroup
+}
+
+func NewWorkers() *Workers {
+	return &Workers{running: make(map[string]bool)}
+}
+
+// Go runs the job in its own goroutine. A job that returns or panics is
+// reported as stopped, jobs are meant to run until their context is
+// cancelled.
+func (w *Workers) Go(name string, job func()) {
+	w.mu.Lock()
+	w.running[name] = true
+	w.mu.Unlock()
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		defer func() {
+			if rec := recover(); rec != nil {
+				logrus.WithFields(logrus.Fields{
+					"job":   name,
+					"panic": rec,
+					"stack": string(debug.Stack()),
+				}).Error("job panicked")
+			}
+
+			w.mu.Lock()
+			w.running[name] = false
+			w.mu.Unlock()
+		}()
+
+		job()
+	}()
+}
+
+// Stopped returns the names of the jobs that are no longer running.
+func (w *Workers) Stopped() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var stopped []string
+	for name, running := range w.running {
+		if !running {
+			stopped = append(stopped, name)
+		}
+	}
+	sort.Strings(stopped)
+
+	return stopped
+}
+
+// Wait waits for the jobs to return, or for ctx to be done.
+func (w *Workers) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
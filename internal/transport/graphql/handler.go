@@ -0,0 +1,120 @@
+package graphql
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	graphql "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+// maxDepth limits how deeply queries may nest, e.g. list.items.list.items.
+const maxDepth = 8
+
+//go:embed schema.graphql
+var schema string
+
+type Auth interface {
+	ParseToken(accesToken string) (int, error)
+	GetUser(ctx context.Context, userId int) (domain.User, error)
+}
+
+type TodoList interface {
+	CreateList(ctx context.Context, userId int, todoList domain.TodoList) (int, error)
+	GetAllLists(ctx context.Context, userId int, withArchived bool) ([]domain.TodoList, error)
+	GetListById(ctx context.Context, userId, listId int) (domain.TodoList, error)
+	GetListsByItems(ctx context.Context, userId int, itemIds []int) (map[int]domain.TodoList, error)
+	UpdateList(ctx context.Context, userId, listId int, input domain.UpdateListInput) error
+	DeleteList(ctx context.Context, userId, listId int) error
+}
+
+type TodoItem interface {
+	CreateItem(ctx context.Context, userId, listId int, input domain.TodoItem) (int, error)
+	GetItemById(ctx context.Context, userId, itemId int) (domain.TodoItem, error)
+	GetItemsByLists(ctx context.Context, userId int, listIds []int) (map[int][]domain.TodoItem, error)
+	UpdateItem(ctx context.Context, userId, itemId int, input domain.UpdateItemInput) error
+	DeleteItem(ctx context.Context, userId, itemId int) error
+	MoveItem(ctx context.Context, userId, itemId int, input domain.MoveItemInput) error
+}
+
+type Event interface {
+	Subscribe(userId int) (<-chan domain.ChangeEvent, func())
+}
+
+// Handler serves GraphQL queries and mutations over HTTP and
+// subscriptions over WebSocket.
+type Handler struct {
+	schema *graphql.Schema
+	auth   Auth
+	lists  TodoList
+	items  TodoItem
+	events Event
+}
+
+func NewHandler(auth Auth, lists TodoList, items TodoItem, events Event) *Handler {
+	h := &Handler{
+		auth:   auth,
+		lists:  lists,
+		items:  items,
+		events: events,
+	}
+
+	h.schema = graphql.MustParseSchema(schema, &resolver{h}, graphql.MaxDepth(maxDepth))
+
+	return h
+}
+
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// ServeQuery executes a query or mutation on behalf of the user.
+func (h *Handler) ServeQuery(w http.ResponseWriter, r *http.Request, userId int) {
+	var req request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, &graphql.Response{
+			Errors: []*gqlerrors.QueryError{gqlerrors.Errorf("invalid request body")},
+		})
+		return
+	}
+
+	ctx := h.withUser(r.Context(), userId)
+	resp := h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+type ctxKey int
+
+const (
+	userIdKey ctxKey = iota
+	loadersKey
+)
+
+// withUser sets the user the request is resolved for, and the request's
+// loaders, so that each query batches its lookups separately.
+func (h *Handler) withUser(ctx context.Context, userId int) context.Context {
+	ctx = context.WithValue(ctx, userIdKey, userId)
+	return context.WithValue(ctx, loadersKey, newLoaders(h, userId))
+}
+
+func ctxUserId(ctx context.Context) (int, error) {
+	userId, ok := ctx.Value(userIdKey).(int)
+	if !ok {
+		return 0, errors.New("user id not found")
+	}
+
+	return userId, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,62 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/graph-gophers/dataloader/v7"
+)
+
+// loaderWait is how long a loader collects keys before running its batch,
+// resolving a field for every list or item in one query instead of one each.
+const loaderWait = 2 * time.Millisecond
+
+var errListNotFound = errors.New("list not found")
+
+type loaders struct {
+	itemsByList *dataloader.Loader[int, []domain.TodoItem]
+	listByItem  *dataloader.Loader[int, domain.TodoList]
+}
+
+func newLoaders(h *Handler, userId int) *loaders {
+	return &loaders{
+		itemsByList: dataloader.NewBatchedLoader(func(ctx context.Context, listIds []int) []*dataloader.Result[[]domain.TodoItem] {
+			results := make([]*dataloader.Result[[]domain.TodoItem], len(listIds))
+
+			items, err := h.items.GetItemsByLists(ctx, userId, listIds)
+			for i, listId := range listIds {
+				results[i] = &dataloader.Result[[]domain.TodoItem]{Data: items[listId], Error: err}
+			}
+
+			return results
+		}, dataloader.WithWait[int, []domain.TodoItem](loaderWait)),
+
+		listByItem: dataloader.NewBatchedLoader(func(ctx context.Context, itemIds []int) []*dataloader.Result[domain.TodoList] {
+			results := make([]*dataloader.Result[domain.TodoList], len(itemIds))
+
+			lists, err := h.lists.GetListsByItems(ctx, userId, itemIds)
+			for i, itemId := range itemIds {
+				list, ok := lists[itemId]
+				if !ok && err == nil {
+					results[i] = &dataloader.Result[domain.TodoList]{Error: errListNotFound}
+					continue
+				}
+
+				results[i] = &dataloader.Result[domain.TodoList]{Data: list, Error: err}
+			}
+
+			return results
+		}, dataloader.WithWait[int, domain.TodoList](loaderWait)),
+	}
+}
+
+func ctxLoaders(ctx context.Context) (*loaders, error) {
+	l, ok := ctx.Value(loadersKey).(*loaders)
+	if !ok {
+		return nil, errors.New("loaders not found")
+	}
+
+	return l, nil
+}
@@ -0,0 +1,352 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// resolver is the root resolver of queries, mutations and subscriptions.
+type resolver struct {
+	h *Handler
+}
+
+func (r *resolver) Me(ctx context.Context) (*userResolver, error) {
+	userId, err := ctxUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := r.h.auth.GetUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &userResolver{h: r.h, user: user}, nil
+}
+
+type listsArgs struct {
+	Archived bool
+}
+
+func (r *resolver) Lists(ctx context.Context, args listsArgs) ([]*listResolver, error) {
+	userId, err := ctxUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.h.resolveLists(ctx, userId, args)
+}
+
+type idArgs struct {
+	Id graphql.ID
+}
+
+func (r *resolver) List(ctx context.Context, args idArgs) (*listResolver, error) {
+	return r.resolveList(ctx, args.Id)
+}
+
+func (r *resolver) Item(ctx context.Context, args idArgs) (*itemResolver, error) {
+	return r.resolveItem(ctx, args.Id)
+}
+
+type createListArgs struct {
+	Input struct {
+		Title       string
+		Description *string
+		IsTemplate  bool
+	}
+}
+
+func (r *resolver) CreateList(ctx context.Context, args createListArgs) (*listResolver, error) {
+	userId, err := ctxUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list := domain.TodoList{
+		Title:       args.Input.Title,
+		Description: args.Input.Description,
+		IsTemplate:  args.Input.IsTemplate,
+	}
+
+	id, err := r.h.lists.CreateList(ctx, userId, list)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.resolveList(ctx, toId(id))
+}
+
+type updateListArgs struct {
+	Id    graphql.ID
+	Input struct {
+		Title       *string
+		Description *string
+		IsTemplate  *bool
+		Version     *int32
+	}
+}
+
+func (r *resolver) UpdateList(ctx context.Context, args updateListArgs) (*listResolver, error) {
+	userId, listId, err := r.userAndId(ctx, args.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	input := domain.UpdateListInput{
+		Title:       args.Input.Title,
+		Description: args.Input.Description,
+		IsTemplate:  args.Input.IsTemplate,
+		Version:     domain.MatchVersion(toIntPtr(args.Input.Version)),
+	}
+
+	if err := r.h.lists.UpdateList(ctx, userId, listId, input); err != nil {
+		return nil, err
+	}
+
+	return r.resolveList(ctx, args.Id)
+}
+
+func (r *resolver) DeleteList(ctx context.Context, args idArgs) (graphql.ID, error) {
+	userId, listId, err := r.userAndId(ctx, args.Id)
+	if err != nil {
+		return "", err
+	}
+
+	if err := r.h.lists.DeleteList(ctx, userId, listId); err != nil {
+		return "", err
+	}
+
+	return args.Id, nil
+}
+
+type createItemArgs struct {
+	ListId graphql.ID
+	Input  struct {
+		Title       string
+		Description *string
+	}
+}
+
+func (r *resolver) CreateItem(ctx context.Context, args createItemArgs) (*itemResolver, error) {
+	userId, listId, err := r.userAndId(ctx, args.ListId)
+	if err != nil {
+		return nil, err
+	}
+
+	item := domain.TodoItem{
+		Title:       args.Input.Title,
+		Description: args.Input.Description,
+	}
+
+	id, err := r.h.items.CreateItem(ctx, userId, listId, item)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.resolveItem(ctx, toId(id))
+}
+
+type updateItemArgs struct {
+	Id    graphql.ID
+	Input struct {
+		Title       *string
+		Description *string
+		Done        *bool
+		Version     *int32
+	}
+}
+
+func (r *resolver) UpdateItem(ctx context.Context, args updateItemArgs) (*itemResolver, error) {
+	userId, itemId, err := r.userAndId(ctx, args.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	input := domain.UpdateItemInput{
+		Title:       args.Input.Title,
+		Description: args.Input.Description,
+		Done:        args.Input.Done,
+		Version:     domain.MatchVersion(toIntPtr(args.Input.Version)),
+	}
+
+	if err := input.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := r.h.items.UpdateItem(ctx, userId, itemId, input); err != nil {
+		return nil, err
+	}
+
+	return r.resolveItem(ctx, args.Id)
+}
+
+func (r *resolver) DeleteItem(ctx context.Context, args idArgs) (graphql.ID, error) {
+	userId, itemId, err := r.userAndId(ctx, args.Id)
+	if err != nil {
+		return "", err
+	}
+
+	if err := r.h.items.DeleteItem(ctx, userId, itemId); err != nil {
+		return "", err
+	}
+
+	return args.Id, nil
+}
+
+type moveItemArgs struct {
+	Id     graphql.ID
+	ListId graphql.ID
+}
+
+func (r *resolver) MoveItem(ctx context.Context, args moveItemArgs) (*itemResolver, error) {
+	userId, itemId, err := r.userAndId(ctx, args.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	listId, err := parseId(args.ListId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.h.items.MoveItem(ctx, userId, itemId, domain.MoveItemInput{ListId: listId}); err != nil {
+		return nil, err
+	}
+
+	return r.resolveItem(ctx, args.Id)
+}
+
+type changesArgs struct {
+	ListId *graphql.ID
+}
+
+// Changes streams the user's change events until the subscription's
+// context is done.
+func (r *resolver) Changes(ctx context.Context, args changesArgs) (<-chan *changeEventResolver, error) {
+	userId, err := ctxUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var listId int
+	if args.ListId != nil {
+		if listId, err = parseId(*args.ListId); err != nil {
+			return nil, err
+		}
+	}
+
+	events, unsubscribe := r.h.events.Subscribe(userId)
+	out := make(chan *changeEventResolver)
+
+	go func() {
+		defer close(out)
+		defer unsubscribe()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+
+				if listId != 0 && !slices.Contains(event.ListIds, listId) {
+					continue
+				}
+
+				select {
+				case out <- &changeEventResolver{event: event}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func (r *resolver) resolveList(ctx context.Context, id graphql.ID) (*listResolver, error) {
+	userId, listId, err := r.userAndId(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := r.h.lists.GetListById(ctx, userId, listId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &listResolver{list: list}, nil
+}
+
+func (r *resolver) resolveItem(ctx context.Context, id graphql.ID) (*itemResolver, error) {
+	userId, itemId, err := r.userAndId(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := r.h.items.GetItemById(ctx, userId, itemId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &itemResolver{item: item}, nil
+}
+
+func (r *resolver) userAndId(ctx context.Context, id graphql.ID) (int, int, error) {
+	userId, err := ctxUserId(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	parsed, err := parseId(id)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return userId, parsed, nil
+}
+
+func (h *Handler) resolveLists(ctx context.Context, userId int, args listsArgs) ([]*listResolver, error) {
+	lists, err := h.lists.GetAllLists(ctx, userId, args.Archived)
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := make([]*listResolver, len(lists))
+	for i, list := range lists {
+		resolvers[i] = &listResolver{list: list}
+	}
+
+	return resolvers, nil
+}
+
+func parseId(id graphql.ID) (int, error) {
+	parsed, err := strconv.Atoi(string(id))
+	if err != nil {
+		return 0, errors.New("invalid id")
+	}
+
+	return parsed, nil
+}
+
+func toId(id int) graphql.ID {
+	return graphql.ID(strconv.Itoa(id))
+}
+
+func toIntPtr(v *int32) *int {
+	if v == nil {
+		return nil
+	}
+
+	i := int(*v)
+	return &i
+}
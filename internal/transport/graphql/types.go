@@ -0,0 +1,178 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+type userResolver struct {
+	h    *Handler
+	user domain.User
+}
+
+func (r *userResolver) Id() graphql.ID {
+	return toId(r.user.Id)
+}
+
+func (r *userResolver) Name() string {
+	return r.user.Name
+}
+
+func (r *userResolver) Email() string {
+	return r.user.Email
+}
+
+func (r *userResolver) Lists(ctx context.Context, args listsArgs) ([]*listResolver, error) {
+	return r.h.resolveLists(ctx, r.user.Id, args)
+}
+
+type listResolver struct {
+	list domain.TodoList
+}
+
+func (r *listResolver) Id() graphql.ID {
+	return toId(r.list.Id)
+}
+
+func (r *listResolver) Title() string {
+	return r.list.Title
+}
+
+func (r *listResolver) Description() *string {
+	return r.list.Description
+}
+
+func (r *listResolver) IsTemplate() bool {
+	return r.list.IsTemplate
+}
+
+func (r *listResolver) Archived() bool {
+	return r.list.Archived
+}
+
+func (r *listResolver) Version() int32 {
+	return int32(r.list.Version)
+}
+
+func (r *listResolver) Items(ctx context.Context) ([]*itemResolver, error) {
+	l, err := ctxLoaders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := l.itemsByList.Load(ctx, r.list.Id)()
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := make([]*itemResolver, len(items))
+	for i, item := range items {
+		resolvers[i] = &itemResolver{item: item, list: &r.list}
+	}
+
+	return resolvers, nil
+}
+
+type itemResolver struct {
+	item domain.TodoItem
+	// list is set when the item was resolved through its list.
+	list *domain.TodoList
+}
+
+func (r *itemResolver) Id() graphql.ID {
+	return toId(r.item.Id)
+}
+
+func (r *itemResolver) Title() string {
+	return r.item.Title
+}
+
+func (r *itemResolver) Description() *string {
+	return r.item.Description
+}
+
+func (r *itemResolver) Done() bool {
+	return r.item.Done
+}
+
+func (r *itemResolver) Version() int32 {
+	return int32(r.item.Version)
+}
+
+func (r *itemResolver) List(ctx context.Context) (*listResolver, error) {
+	if r.list != nil {
+		return &listResolver{list: *r.list}, nil
+	}
+
+	l, err := ctxLoaders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := l.listByItem.Load(ctx, r.item.Id)()
+	if err != nil {
+		return nil, err
+	}
+
+	return &listResolver{list: list}, nil
+}
+
+type changeEventResolver struct {
+	event domain.ChangeEvent
+}
+
+func (r *changeEventResolver) Id() graphql.ID {
+	return toId(r.event.Id)
+}
+
+func (r *changeEventResolver) EntityType() string {
+	return r.event.EntityType
+}
+
+func (r *changeEventResolver) EntityId() graphql.ID {
+	return toId(r.event.EntityId)
+}
+
+func (r *changeEventResolver) ActorId() *graphql.ID {
+	if r.event.ActorId == nil {
+		return nil
+	}
+
+	id := toId(*r.event.ActorId)
+	return &id
+}
+
+func (r *changeEventResolver) Action() string {
+	return r.event.Action
+}
+
+func (r *changeEventResolver) Fields() (*string, error) {
+	if r.event.Fields == nil {
+		return nil, nil
+	}
+
+	fields, err := json.Marshal(r.event.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	s := string(fields)
+	return &s, nil
+}
+
+func (r *changeEventResolver) ListIds() []graphql.ID {
+	ids := make([]graphql.ID, len(r.event.ListIds))
+	for i, id := range r.event.ListIds {
+		ids[i] = toId(id)
+	}
+
+	return ids
+}
+
+func (r *changeEventResolver) CreatedAt() string {
+	return r.event.CreatedAt.Format(time.RFC3339)
+}
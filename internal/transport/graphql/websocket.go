@@ -0,0 +1,260 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+// Subscriptions are served over the graphql-transport-ws protocol:
+// https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md
+const (
+	subprotocol = "graphql-transport-ws"
+
+	msgConnectionInit = "connection_init"
+	msgConnectionAck  = "connection_ack"
+	msgPing           = "ping"
+	msgPong           = "pong"
+	msgSubscribe      = "subscribe"
+	msgNext           = "next"
+	msgError          = "error"
+	msgComplete       = "complete"
+
+	closeBadRequest           = 4400
+	closeUnauthorized         = 4401
+	closeForbidden            = 4403
+	closeSubprotocol          = 4406
+	closeInitTimeout          = 4408
+	closeSubscriberExists     = 4409
+	closeTooManyInitRequests  = 4429
+	connectionInitTimeout     = 10 * time.Second
+	writeTimeout              = 10 * time.Second
+	authorizationPayloadField = "Authorization"
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	Subprotocols:    []string{subprotocol},
+}
+
+type message struct {
+	Id      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+type connection struct {
+	h    *Handler
+	ws   *websocket.Conn
+	ctx  context.Context
+	mu   sync.Mutex
+	subs map[string]context.CancelFunc
+	// userId is set once the connection is initialised.
+	userId int
+}
+
+// ServeWebSocket upgrades the request and serves subscriptions, queries and
+// mutations until the client disconnects. The access token is read from the
+// connection_init payload's Authorization field, or from the request's
+// Authorization header.
+func (h *Handler) ServeWebSocket(w http.ResponseWriter, r *http.Request) {
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer ws.Close()
+
+	if ws.Subprotocol() != subprotocol {
+		closeConn(ws, closeSubprotocol, "Subprotocol not acceptable")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	conn := &connection{
+		h:    h,
+		ws:   ws,
+		ctx:  ctx,
+		subs: make(map[string]context.CancelFunc),
+	}
+
+	ws.SetReadDeadline(time.Now().Add(connectionInitTimeout))
+
+	for {
+		var msg message
+		if err := ws.ReadJSON(&msg); err != nil {
+			var netErr interface{ Timeout() bool }
+			if conn.userId == 0 && errors.As(err, &netErr) && netErr.Timeout() {
+				closeConn(ws, closeInitTimeout, "Connection initialisation timeout")
+			}
+
+			return
+		}
+
+		if code, reason := conn.handle(msg, r.Header.Get("Authorization")); code != 0 {
+			closeConn(ws, code, reason)
+			return
+		}
+	}
+}
+
+// handle processes a client message, returning a close code and reason
+// when the connection has to be closed.
+func (c *connection) handle(msg message, authHeader string) (int, string) {
+	switch msg.Type {
+	case msgConnectionInit:
+		if c.userId != 0 {
+			return closeTooManyInitRequests, "Too many initialisation requests"
+		}
+
+		userId, err := c.authenticate(msg.Payload, authHeader)
+		if err != nil {
+			return closeForbidden, "Forbidden"
+		}
+
+		c.userId = userId
+		c.ws.SetReadDeadline(time.Time{})
+		c.write(message{Type: msgConnectionAck})
+
+	case msgPing:
+		c.write(message{Type: msgPong})
+
+	case msgPong:
+
+	case msgSubscribe:
+		if c.userId == 0 {
+			return closeUnauthorized, "Unauthorized"
+		}
+
+		var req request
+		if msg.Id == "" || json.Unmarshal(msg.Payload, &req) != nil {
+			return closeBadRequest, "Invalid subscribe message"
+		}
+
+		c.mu.Lock()
+		_, exists := c.subs[msg.Id]
+		c.mu.Unlock()
+		if exists {
+			return closeSubscriberExists, "Subscriber for " + msg.Id + " already exists"
+		}
+
+		c.subscribe(msg.Id, req)
+
+	case msgComplete:
+		c.mu.Lock()
+		if cancel, ok := c.subs[msg.Id]; ok {
+			cancel()
+			delete(c.subs, msg.Id)
+		}
+		c.mu.Unlock()
+
+	default:
+		return closeBadRequest, "Invalid message type"
+	}
+
+	return 0, ""
+}
+
+func (c *connection) authenticate(payload json.RawMessage, authHeader string) (int, error) {
+	var init map[string]interface{}
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &init); err != nil {
+			return 0, err
+		}
+	}
+
+	if header, ok := init[authorizationPayloadField].(string); ok {
+		authHeader = header
+	}
+
+	token, ok := strings.CutPrefix(authHeader, "Bearer ")
+	if !ok || token == "" {
+		return 0, errors.New("invalid auth header")
+	}
+
+	return c.h.auth.ParseToken(token)
+}
+
+// subscribe runs the operation and streams its results to the client until
+// they end or the client completes the operation.
+func (c *connection) subscribe(id string, req request) {
+	ctx, cancel := context.WithCancel(c.h.withUser(c.ctx, c.userId))
+
+	c.mu.Lock()
+	c.subs[id] = cancel
+	c.mu.Unlock()
+
+	responses, err := c.h.schema.Subscribe(ctx, req.Query, req.OperationName, req.Variables)
+	if err != nil {
+		c.finish(id, cancel)
+		payload, _ := json.Marshal([]map[string]string{{"message": err.Error()}})
+		c.write(message{Id: id, Type: msgError, Payload: payload})
+		return
+	}
+
+	go func() {
+		// The responses must be drained even after the client completes the
+		// operation, the schema blocks until they are.
+		for resp := range responses {
+			if ctx.Err() != nil {
+				continue
+			}
+
+			payload, err := json.Marshal(resp)
+			if err != nil {
+				logrus.WithField("id", id).Error(err)
+				continue
+			}
+
+			c.write(message{Id: id, Type: msgNext, Payload: payload})
+		}
+
+		if c.finish(id, cancel) {
+			c.write(message{Id: id, Type: msgComplete})
+		}
+	}()
+}
+
+// finish removes the operation, reporting whether it was still running,
+// i.e. whether the client has yet to be told that it completed.
+func (c *connection) finish(id string, cancel context.CancelFunc) bool {
+	defer cancel()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ctx.Err() != nil {
+		return false
+	}
+
+	if _, ok := c.subs[id]; !ok {
+		return false
+	}
+
+	delete(c.subs, id)
+	return true
+}
+
+func (c *connection) write(msg message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ws.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if err := c.ws.WriteJSON(msg); err != nil {
+		logrus.WithField("type", msg.Type).Debug(err)
+	}
+}
+
+func closeConn(ws *websocket.Conn, code int, reason string) {
+	ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason),
+		time.Now().Add(writeTimeout))
+}
@@ -0,0 +1,56 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	todov1 "github.com/SavelyDev/crud-app/pkg/api/todo/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (h *Handler) SignUp(ctx context.Context, req *todov1.SignUpRequest) (*todov1.SignUpResponse, error) {
+	if req.Name == "" || req.Email == "" || req.Password == "" {
+		return nil, status.Error(codes.InvalidArgument, "name, email and password are required")
+	}
+
+	id, err := h.AuthService.SignUp(ctx, domain.User{
+		Name:         req.Name,
+		Email:        req.Email,
+		PasswordHash: req.Password,
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &todov1.SignUpResponse{Id: int64(id)}, nil
+}
+
+func (h *Handler) SignIn(ctx context.Context, req *todov1.SignInRequest) (*todov1.Tokens, error) {
+	if req.Email == "" || req.Password == "" {
+		return nil, status.Error(codes.InvalidArgument, "email and password are required")
+	}
+
+	accesToken, refreshToken, err := h.AuthService.SignIn(ctx, domain.SignInInput{
+		Email:    req.Email,
+		Password: req.Password,
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &todov1.Tokens{AccessToken: accesToken, RefreshToken: refreshToken}, nil
+}
+
+func (h *Handler) Refresh(ctx context.Context, req *todov1.RefreshRequest) (*todov1.Tokens, error) {
+	if req.RefreshToken == "" {
+		return nil, status.Error(codes.InvalidArgument, "refresh token is required")
+	}
+
+	accesToken, refreshToken, err := h.AuthService.RefreshToken(ctx, req.RefreshToken)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &todov1.Tokens{AccessToken: accesToken, RefreshToken: refreshToken}, nil
+}
@@ -0,0 +1,112 @@
+package grpc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	todov1 "github.com/SavelyDev/crud-app/pkg/api/todo/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+type Auth interface {
+	SignUp(ctx context.Context, user domain.User) (int, error)
+	SignIn(ctx context.Context, user domain.SignInInput) (string, string, error)
+	ParseToken(accesToken string) (int, error)
+	RefreshToken(ctx context.Context, refreshToken string) (string, string, error)
+}
+
+type TodoList interface {
+	CreateList(ctx context.Context, userId int, todoList domain.TodoList) (int, error)
+	GetAllLists(ctx context.Context, userId int, withArchived bool) ([]domain.TodoList, error)
+	GetListById(ctx context.Context, userId, listId int) (domain.TodoList, error)
+	UpdateList(ctx context.Context, userId, listId int, input domain.UpdateListInput) error
+	DeleteList(ctx context.Context, userId, listId int) error
+}
+
+type TodoItem interface {
+	CreateItem(ctx context.Context, userId, listId int, input domain.TodoItem) (int, error)
+	GetAllItems(ctx context.Context, userId, listId int) ([]domain.TodoItem, error)
+	GetItemById(ctx context.Context, userId, itemId int) (domain.TodoItem, error)
+	UpdateItem(ctx context.Context, userId, itemId int, input domain.UpdateItemInput) error
+	DeleteItem(ctx context.Context, userId, itemId int) error
+	MoveItem(ctx context.Context, userId, itemId int, input domain.MoveItemInput) error
+}
+
+// Handler implements the gRPC API on top of the services.
+type Handler struct {
+	todov1.UnimplementedAuthServiceServer
+	todov1.UnimplementedListServiceServer
+	todov1.UnimplementedItemServiceServer
+
+	AuthService     Auth
+	TodoListService TodoList
+	TodoItemService TodoItem
+	// DBTimeout bounds the database work of a call, 0 leaves it unbounded.
+	DBTimeout time.Duration
+
+	health *health.Server
+}
+
+func NewHandler(auth Auth, todoList TodoList, todoItem TodoItem, dbTimeout time.Duration) *Handler {
+	return &Handler{
+		AuthService:     auth,
+		TodoListService: todoList,
+		TodoItemService: todoItem,
+		DBTimeout:       dbTimeout,
+	}
+}
+
+func (h *Handler) InitServer() *grpc.Server {
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(h.unaryAuth, h.unaryTimeout),
+		grpc.ChainStreamInterceptor(h.streamAuth),
+	)
+
+	todov1.RegisterAuthServiceServer(srv, h)
+	todov1.RegisterListServiceServer(srv, h)
+	todov1.RegisterItemServiceServer(srv, h)
+
+	h.health = health.NewServer()
+	for service := range srv.GetServiceInfo() {
+		h.health.SetServingStatus(service, healthpb.HealthCheckResponse_SERVING)
+	}
+	healthpb.RegisterHealthServer(srv, h.health)
+
+	reflection.Register(srv)
+
+	return srv
+}
+
+// Drain reports every service as NOT_SERVING from now on, so that clients
+// move to other instances before the server shuts down.
+func (h *Handler) Drain() {
+	if h.health != nil {
+		h.health.Shutdown()
+	}
+}
+
+// toStatus maps service errors to gRPC status errors.
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return status.Error(codes.NotFound, "not found")
+	case errors.Is(err, domain.ErrVersionMismatch):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, domain.ErrNotListOwner):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
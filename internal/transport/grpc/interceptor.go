@@ -0,0 +1,122 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	todov1 "github.com/SavelyDev/crud-app/pkg/api/todo/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const authorizationMetadata = "authorization"
+
+type ctxKey int
+
+const userIdKey ctxKey = iota
+
+// publicServices don't require an access token.
+var publicServices = []string{
+	todov1.AuthService_ServiceDesc.ServiceName,
+	healthpb.Health_ServiceDesc.ServiceName,
+	"grpc.reflection.v1.ServerReflection",
+	"grpc.reflection.v1alpha.ServerReflection",
+}
+
+func (h *Handler) unaryAuth(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := h.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// unaryTimeout bounds the call's database work by DBTimeout, a shorter
+// client deadline still applies.
+func (h *Handler) unaryTimeout(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	if h.DBTimeout <= 0 {
+		return handler(ctx, req)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.DBTimeout)
+	defer cancel()
+
+	return handler(ctx, req)
+}
+
+func (h *Handler) streamAuth(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+	ctx, err := h.authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+}
+
+// authenticate sets the user id from the access token in the request
+// metadata, unless the method is public.
+func (h *Handler) authenticate(ctx context.Context, fullMethod string) (context.Context, error) {
+	for _, service := range publicServices {
+		if strings.HasPrefix(fullMethod, "/"+service+"/") {
+			return ctx, nil
+		}
+	}
+
+	token, err := getTokenFromMetadata(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	userId, err := h.AuthService.ParseToken(token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	return context.WithValue(ctx, userIdKey, userId), nil
+}
+
+func getTokenFromMetadata(ctx context.Context) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	values := md.Get(authorizationMetadata)
+	if len(values) == 0 {
+		return "", errors.New("empty authorization metadata")
+	}
+
+	token, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok {
+		return "", errors.New("invalid authorization metadata")
+	}
+
+	if token == "" {
+		return "", errors.New("token is empty")
+	}
+
+	return token, nil
+}
+
+func getUserId(ctx context.Context) (int, error) {
+	userId, ok := ctx.Value(userIdKey).(int)
+	if !ok {
+		return 0, status.Error(codes.Internal, "user id not found")
+	}
+
+	return userId, nil
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,132 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	todov1 "github.com/SavelyDev/crud-app/pkg/api/todo/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (h *Handler) CreateItem(ctx context.Context, req *todov1.CreateItemRequest) (*todov1.Item, error) {
+	userId, err := getUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Title == "" {
+		return nil, status.Error(codes.InvalidArgument, "title is required")
+	}
+
+	id, err := h.TodoItemService.CreateItem(ctx, userId, int(req.ListId), domain.TodoItem{
+		Title:       req.Title,
+		Description: req.Description,
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return h.getItem(ctx, userId, id)
+}
+
+func (h *Handler) GetItem(ctx context.Context, req *todov1.GetItemRequest) (*todov1.Item, error) {
+	userId, err := getUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.getItem(ctx, userId, int(req.Id))
+}
+
+func (h *Handler) GetItems(ctx context.Context, req *todov1.GetItemsRequest) (*todov1.GetItemsResponse, error) {
+	userId, err := getUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := h.TodoItemService.GetAllItems(ctx, userId, int(req.ListId))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &todov1.GetItemsResponse{Items: make([]*todov1.Item, len(items))}
+	for i, item := range items {
+		resp.Items[i] = toItem(item)
+	}
+
+	return resp, nil
+}
+
+func (h *Handler) UpdateItem(ctx context.Context, req *todov1.UpdateItemRequest) (*todov1.Item, error) {
+	userId, err := getUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	input := domain.UpdateItemInput{
+		Title:       req.Title,
+		Description: req.Description,
+		Done:        req.Done,
+		Version:     domain.MatchVersion(toIntPtr(req.Version)),
+	}
+
+	if err := input.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := h.TodoItemService.UpdateItem(ctx, userId, int(req.Id), input); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return h.getItem(ctx, userId, int(req.Id))
+}
+
+func (h *Handler) DeleteItem(ctx context.Context, req *todov1.DeleteItemRequest) (*todov1.DeleteItemResponse, error) {
+	userId, err := getUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.TodoItemService.DeleteItem(ctx, userId, int(req.Id)); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &todov1.DeleteItemResponse{}, nil
+}
+
+func (h *Handler) MoveItem(ctx context.Context, req *todov1.MoveItemRequest) (*todov1.Item, error) {
+	userId, err := getUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ListId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "list_id is required")
+	}
+
+	if err := h.TodoItemService.MoveItem(ctx, userId, int(req.Id), domain.MoveItemInput{ListId: int(req.ListId)}); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return h.getItem(ctx, userId, int(req.Id))
+}
+
+func (h *Handler) getItem(ctx context.Context, userId, itemId int) (*todov1.Item, error) {
+	item, err := h.TodoItemService.GetItemById(ctx, userId, itemId)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toItem(item), nil
+}
+
+func toItem(item domain.TodoItem) *todov1.Item {
+	return &todov1.Item{
+		Id:          int64(item.Id),
+		Title:       item.Title,
+		Description: item.Description,
+		Done:        item.Done,
+		Version:     int64(item.Version),
+	}
+}
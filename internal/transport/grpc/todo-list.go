@@ -0,0 +1,126 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	todov1 "github.com/SavelyDev/crud-app/pkg/api/todo/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (h *Handler) CreateList(ctx context.Context, req *todov1.CreateListRequest) (*todov1.List, error) {
+	userId, err := getUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Title == "" {
+		return nil, status.Error(codes.InvalidArgument, "title is required")
+	}
+
+	id, err := h.TodoListService.CreateList(ctx, userId, domain.TodoList{
+		Title:       req.Title,
+		Description: req.Description,
+		IsTemplate:  req.IsTemplate,
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return h.getList(ctx, userId, id)
+}
+
+func (h *Handler) GetList(ctx context.Context, req *todov1.GetListRequest) (*todov1.List, error) {
+	userId, err := getUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.getList(ctx, userId, int(req.Id))
+}
+
+func (h *Handler) GetLists(ctx context.Context, req *todov1.GetListsRequest) (*todov1.GetListsResponse, error) {
+	userId, err := getUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lists, err := h.TodoListService.GetAllLists(ctx, userId, req.IncludeArchived)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &todov1.GetListsResponse{Lists: make([]*todov1.List, len(lists))}
+	for i, list := range lists {
+		resp.Lists[i] = toList(list)
+	}
+
+	return resp, nil
+}
+
+func (h *Handler) UpdateList(ctx context.Context, req *todov1.UpdateListRequest) (*todov1.List, error) {
+	userId, err := getUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	input := domain.UpdateListInput{
+		Title:       req.Title,
+		Description: req.Description,
+		IsTemplate:  req.IsTemplate,
+		Version:     domain.MatchVersion(toIntPtr(req.Version)),
+	}
+
+	if err := input.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := h.TodoListService.UpdateList(ctx, userId, int(req.Id), input); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return h.getList(ctx, userId, int(req.Id))
+}
+
+func (h *Handler) DeleteList(ctx context.Context, req *todov1.DeleteListRequest) (*todov1.DeleteListResponse, error) {
+	userId, err := getUserId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.TodoListService.DeleteList(ctx, userId, int(req.Id)); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &todov1.DeleteListResponse{}, nil
+}
+
+func (h *Handler) getList(ctx context.Context, userId, listId int) (*todov1.List, error) {
+	list, err := h.TodoListService.GetListById(ctx, userId, listId)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toList(list), nil
+}
+
+func toList(list domain.TodoList) *todov1.List {
+	return &todov1.List{
+		Id:          int64(list.Id),
+		Title:       list.Title,
+		Description: list.Description,
+		IsTemplate:  list.IsTemplate,
+		Archived:    list.Archived,
+		Version:     int64(list.Version),
+	}
+}
+
+func toIntPtr(v *int64) *int {
+	if v == nil {
+		return nil
+	}
+
+	i := int(*v)
+	return &i
+}
@@ -0,0 +1,191 @@
+package rest
+
+import (
+	"database/sql"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/pkg/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead leaves room for the multipart headers and boundaries
+// around the file itself when limiting the request body size.
+const multipartOverhead = 1 << 20
+
+// @Summary Upload Attachment
+// @Description Attach a file to a todo item
+// @Security ApiKeyAuth
+// @Tags attachments
+// @Accept mpfd
+// @Produce json
+// @Param id path int true "Item ID"
+// @Param file formData file true "File to attach"
+// @Success 200 {object} domain.Attachment
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 413 {object} httputil.HTTPError
+// @Failure 415 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/items/{id}/attachments [post]
+func (h *Handler) uploadAttachment(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("invalid id param"))
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.AttachmentService.MaxSize()+multipartOverhead)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			httputil.NewError(c, http.StatusRequestEntityTooLarge, domain.ErrAttachmentTooLarge)
+			return
+		}
+
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.AttachmentService.CreateAttachment(c.Request.Context(), userId, itemId,
+		header.Filename, header.Size, file)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAttachmentTooLarge):
+			httputil.NewError(c, http.StatusRequestEntityTooLarge, err)
+		case errors.Is(err, domain.ErrUnsupportedMediaType):
+			httputil.NewError(c, http.StatusUnsupportedMediaType, err)
+		default:
+			httputil.NewError(c, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, attachment)
+}
+
+// @Summary Get Attachments
+// @Description Get the attachments of a todo item with signed download URLs
+// @Security ApiKeyAuth
+// @Tags attachments
+// @Produce json
+// @Param id path int true "Item ID"
+// @Success 200 {array} domain.Attachment
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/items/{id}/attachments [get]
+func (h *Handler) getAttachments(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("invalid id param"))
+		return
+	}
+
+	attachments, err := h.AttachmentService.GetAttachments(c.Request.Context(), userId, itemId)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+// @Summary Delete Attachment
+// @Description Delete an attachment, its file is removed from storage in the background
+// @Security ApiKeyAuth
+// @Tags attachments
+// @Produce json
+// @Param id path int true "Attachment ID"
+// @Success 200 {string} string "ok"
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/attachments/{id} [delete]
+func (h *Handler) deleteAttachment(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	attachmentId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("invalid id param"))
+		return
+	}
+
+	if err := h.AttachmentService.DeleteAttachment(c.Request.Context(), userId, attachmentId); err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// @Summary Download Attachment
+// @Description Download an attachment using a signed URL
+// @Tags attachments
+// @Produce octet-stream
+// @Param id path int true "Attachment ID"
+// @Param expires query int true "Signature expiry as unix time"
+// @Param signature query string true "URL signature"
+// @Success 200 {file} file
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 403 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /attachments/{id}/download [get]
+func (h *Handler) downloadAttachment(c *gin.Context) {
+	attachmentId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("invalid id param"))
+		return
+	}
+
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("invalid expires param"))
+		return
+	}
+
+	attachment, blob, err := h.AttachmentService.OpenAttachment(c.Request.Context(), attachmentId,
+		expires, c.Query("signature"))
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidSignature):
+			httputil.NewError(c, http.StatusForbidden, err)
+		case errors.Is(err, sql.ErrNoRows):
+			httputil.NewError(c, http.StatusNotFound, errors.New("attachment not found"))
+		default:
+			httputil.NewError(c, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	defer blob.Close()
+
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, blob, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}),
+	})
+}
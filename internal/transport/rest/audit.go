@@ -0,0 +1,212 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/pkg/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Get List History
+// @Description Get the change history of a todo list, newest first
+// @Security ApiKeyAuth
+// @Tags history
+// @Produce json
+// @Param id path int true "List ID"
+// @Param limit query int false "Page size"
+// @Param offset query int false "Page offset"
+// @Success 200 {array} domain.AuditEvent
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/lists/{id}/history [get]
+func (h *Handler) getListHistory(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	listId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("invalid id param"))
+		return
+	}
+
+	page, err := getPagination(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	events, err := h.AuditService.GetListHistory(c.Request.Context(), userId, listId, page)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+// @Summary Get Item History
+// @Description Get the change history of a todo item, newest first
+// @Security ApiKeyAuth
+// @Tags history
+// @Produce json
+// @Param id path int true "Item ID"
+// @Param limit query int false "Page size"
+// @Param offset query int false "Page offset"
+// @Success 200 {array} domain.AuditEvent
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/items/{id}/history [get]
+func (h *Handler) getItemHistory(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("invalid id param"))
+		return
+	}
+
+	page, err := getPagination(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	events, err := h.AuditService.GetItemHistory(c.Request.Context(), userId, itemId, page)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+// @Summary Get Audit Events
+// @Description Query the audit log across all users, admin only
+// @Security ApiKeyAuth
+// @Tags admin
+// @Produce json
+// @Param actor_id query int false "Acting user ID"
+// @Param entity_type query string false "Entity type" Enums(list, item)
+// @Param entity_id query int false "Entity ID"
+// @Param action query string false "Action" Enums(create, update, delete, restore, move)
+// @Param from query string false "Events at or after this RFC 3339 time"
+// @Param to query string false "Events before this RFC 3339 time"
+// @Param limit query int false "Page size"
+// @Param offset query int false "Page offset"
+// @Success 200 {array} domain.AuditEvent
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 403 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/admin/audit [get]
+func (h *Handler) getAuditEvents(c *gin.Context) {
+	var filter domain.AuditFilter
+	var err error
+
+	filter.Pagination, err = getPagination(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if actorId := c.Query("actor_id"); actorId != "" {
+		id, err := strconv.Atoi(actorId)
+		if err != nil {
+			httputil.NewError(c, http.StatusBadRequest, errors.New("invalid actor_id param"))
+			return
+		}
+
+		filter.ActorId = &id
+	}
+
+	if entityId := c.Query("entity_id"); entityId != "" {
+		id, err := strconv.Atoi(entityId)
+		if err != nil {
+			httputil.NewError(c, http.StatusBadRequest, errors.New("invalid entity_id param"))
+			return
+		}
+
+		filter.EntityId = &id
+	}
+
+	if from := c.Query("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			httputil.NewError(c, http.StatusBadRequest, errors.New("invalid from param"))
+			return
+		}
+
+		filter.From = &t
+	}
+
+	if to := c.Query("to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			httputil.NewError(c, http.StatusBadRequest, errors.New("invalid to param"))
+			return
+		}
+
+		filter.To = &t
+	}
+
+	filter.EntityType = c.Query("entity_type")
+	filter.Action = c.Query("action")
+
+	events, err := h.AuditService.GetEvents(c.Request.Context(), filter)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+// @Summary Undo
+// @Description Revert a recent update, delete or move performed by the user
+// @Security ApiKeyAuth
+// @Tags history
+// @Produce json
+// @Param eventId path int true "Audit event ID"
+// @Success 200 {string} string "ok"
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 409 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/undo/{eventId} [post]
+func (h *Handler) undo(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	eventId, err := strconv.Atoi(c.Param("eventId"))
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("invalid eventId param"))
+		return
+	}
+
+	if err := h.AuditService.Undo(c.Request.Context(), userId, eventId); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrUndoUnavailable):
+			httputil.NewError(c, http.StatusNotFound, err)
+		case errors.Is(err, domain.ErrEntityChanged):
+			httputil.NewError(c, http.StatusConflict, err)
+		default:
+			httputil.NewError(c, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
@@ -1,7 +1,6 @@
 package rest
 
 import (
-	"fmt"
 	"net/http"
 
 	"github.com/SavelyDev/crud-app/internal/domain"
@@ -27,7 +26,7 @@ func (h *Handler) signUp(c *gin.Context) {
 		return
 	}
 
-	id, err := h.AuthService.SignUp(user)
+	id, err := h.AuthService.SignUp(c.Request.Context(), user)
 	if err != nil {
 		httputil.NewError(c, http.StatusInternalServerError, err)
 		return
@@ -54,13 +53,13 @@ func (h *Handler) signIn(c *gin.Context) {
 		return
 	}
 
-	accesToken, refreshToken, err := h.AuthService.SignIn(credentials)
+	accesToken, refreshToken, err := h.AuthService.SignIn(c.Request.Context(), credentials)
 	if err != nil {
 		httputil.NewError(c, http.StatusInternalServerError, err)
 		return
 	}
 
-	c.Header("Set-Cookie", fmt.Sprintf("refresh-token=%s; HttpOnly", refreshToken))
+	c.Header("Set-Cookie", h.refreshCookie(refreshToken))
 
 	c.JSON(http.StatusOK, gin.H{"acces_token": accesToken})
 }
@@ -72,13 +71,13 @@ func (h *Handler) refresh(c *gin.Context) {
 		return
 	}
 
-	accesToken, refreshToken, err := h.AuthService.RefreshToken(token)
+	accesToken, refreshToken, err := h.AuthService.RefreshToken(c.Request.Context(), token)
 	if err != nil {
 		httputil.NewError(c, http.StatusInternalServerError, err)
 		return
 	}
 
-	c.Header("Set-Cookie", fmt.Sprintf("refresh-token=%s; HttpOnly", refreshToken))
+	c.Header("Set-Cookie", h.refreshCookie(refreshToken))
 
 	c.JSON(http.StatusOK, gin.H{"acces_token": accesToken})
 }
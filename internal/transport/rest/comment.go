@@ -0,0 +1,168 @@
+package rest
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/pkg/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Create Comment
+// @Description Comment on a todo item, mentions of list members by email, e.g. @alice@example.com, notify them
+// @Security ApiKeyAuth
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path int true "Item ID"
+// @Param comment body domain.CommentInput true "Comment text in markdown"
+// @Success 200 {integer} integer 1
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/items/{id}/comments [post]
+func (h *Handler) createComment(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("invalid id param"))
+		return
+	}
+
+	var input domain.CommentInput
+	if err := c.BindJSON(&input); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	id, err := h.CommentService.CreateComment(c.Request.Context(), userId, itemId, input)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id})
+}
+
+// @Summary Get Comments
+// @Description Get the comments of a todo item, oldest first
+// @Security ApiKeyAuth
+// @Tags comments
+// @Produce json
+// @Param id path int true "Item ID"
+// @Param limit query int false "Page size"
+// @Param offset query int false "Page offset"
+// @Success 200 {array} domain.Comment
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/items/{id}/comments [get]
+func (h *Handler) getComments(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("invalid id param"))
+		return
+	}
+
+	page, err := getPagination(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	comments, err := h.CommentService.GetComments(c.Request.Context(), userId, itemId, page)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, comments)
+}
+
+// @Summary Update Comment
+// @Description Edit one of the user's own comments, on an item they can still access
+// @Security ApiKeyAuth
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Param comment body domain.CommentInput true "Comment text in markdown"
+// @Success 200 {string} string "ok"
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/comments/{id} [put]
+func (h *Handler) updateComment(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	commentId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("invalid id param"))
+		return
+	}
+
+	var input domain.CommentInput
+	if err := c.BindJSON(&input); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.CommentService.UpdateComment(c.Request.Context(), userId, commentId, input); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			httputil.NewError(c, http.StatusNotFound, errors.New("comment not found"))
+			return
+		}
+
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// @Summary Delete Comment
+// @Description Delete one of the user's own comments
+// @Security ApiKeyAuth
+// @Tags comments
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Success 200 {string} string "ok"
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/comments/{id} [delete]
+func (h *Handler) deleteComment(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	commentId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("invalid id param"))
+		return
+	}
+
+	if err := h.CommentService.DeleteComment(c.Request.Context(), userId, commentId); err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
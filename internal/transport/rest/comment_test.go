@@ -0,0 +1,54 @@
+package rest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+type fakeComments struct {
+	Comment
+	err error
+}
+
+func (f fakeComments) UpdateComment(ctx context.Context, userId, commentId int, input domain.CommentInput) error {
+	return f.err
+}
+
+func TestUpdateCommentErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "updated", wantStatus: http.StatusOK},
+		{name: "not the author or no longer a member", err: sql.ErrNoRows, wantStatus: http.StatusNotFound},
+		{name: "database error", err: errors.New("connection reset"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{CommentService: fakeComments{err: tt.err}}
+
+			router := gin.New()
+			router.PUT("/comments/:id", func(c *gin.Context) { c.Set(userCtx, 1) }, h.updateComment)
+
+			req := httptest.NewRequest(http.MethodPut, "/comments/3", strings.NewReader(`{"body":"done @bob@example.com"}`))
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	etagHeader        = "ETag"
+	ifMatchHeader     = "If-Match"
+	ifNoneMatchHeader = "If-None-Match"
+)
+
+var errInvalidIfMatch = errors.New("invalid If-Match header")
+
+type entityTag struct {
+	weak   bool
+	opaque string
+}
+
+func versionETag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// getIfMatchVersion returns the versions the If-Match header accepts, or nil
+// when the header is absent or is "*". If-Match uses the strong comparison,
+// so weak tags never match: a header with weak tags only accepts no version
+// and fails the precondition.
+func getIfMatchVersion(c *gin.Context) (domain.VersionMatch, error) {
+	header := strings.TrimSpace(c.GetHeader(ifMatchHeader))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	tags, err := parseETags(header)
+	if err != nil {
+		return nil, errInvalidIfMatch
+	}
+
+	versions := make(domain.VersionMatch, 0, len(tags))
+	for _, tag := range tags {
+		if tag.weak {
+			continue
+		}
+
+		// Tags this server didn't issue can't match, but don't make the
+		// header invalid either.
+		if version, err := strconv.Atoi(tag.opaque); err == nil {
+			versions = append(versions, version)
+		}
+	}
+
+	return versions, nil
+}
+
+// notModified sets the ETag header for the version and reports whether
+// the client's If-None-Match header already has it, writing a 304 if so.
+// If-None-Match uses the weak comparison, W/"3" matches "3".
+func notModified(c *gin.Context, version int) bool {
+	etag := versionETag(version)
+	c.Header(etagHeader, etag)
+
+	header := strings.TrimSpace(c.GetHeader(ifNoneMatchHeader))
+	if header == "" {
+		return false
+	}
+
+	match := header == "*"
+	if tags, err := parseETags(header); err == nil {
+		for _, tag := range tags {
+			match = match || `"`+tag.opaque+`"` == etag
+		}
+	}
+
+	if match {
+		c.Status(http.StatusNotModified)
+	}
+
+	return match
+}
+
+// parseETags parses a comma-separated list of entity tags as defined by
+// RFC 9110, section 8.8.3. Empty list elements are allowed.
+func parseETags(header string) ([]entityTag, error) {
+	var tags []entityTag
+
+	s := header
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			break
+		}
+
+		if s[0] == ',' {
+			s = s[1:]
+			continue
+		}
+
+		var tag entityTag
+		if strings.HasPrefix(s, "W/") {
+			tag.weak = true
+			s = s[2:]
+		}
+
+		if s == "" || s[0] != '"' {
+			return nil, fmt.Errorf("invalid entity tag in %q", header)
+		}
+
+		end := strings.IndexByte(s[1:], '"')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated entity tag in %q", header)
+		}
+
+		tag.opaque = s[1 : end+1]
+		for i := 0; i < len(tag.opaque); i++ {
+			if b := tag.opaque[i]; b < 0x21 || b == 0x7f {
+				return nil, fmt.Errorf("invalid entity tag in %q", header)
+			}
+		}
+		tags = append(tags, tag)
+
+		s = strings.TrimLeft(s[end+2:], " \t")
+		if s != "" && s[0] != ',' {
+			return nil, fmt.Errorf("invalid entity tag list %q", header)
+		}
+	}
+
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("no entity tags in %q", header)
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,122 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+func newTestContext(header, value string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	if value != "" {
+		c.Request.Header.Set(header, value)
+	}
+
+	return c, rec
+}
+
+func TestGetIfMatchVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    domain.VersionMatch
+		wantErr bool
+	}{
+		{name: "absent", header: "", want: nil},
+		{name: "any", header: "*", want: nil},
+		{name: "strong tag", header: `"3"`, want: domain.VersionMatch{3}},
+		{name: "padded", header: `  "3"  `, want: domain.VersionMatch{3}},
+		{name: "list", header: `"3", "5","7"`, want: domain.VersionMatch{3, 5, 7}},
+		{name: "empty list elements", header: `, "3",, "5" ,`, want: domain.VersionMatch{3, 5}},
+		{name: "weak tag", header: `W/"3"`, want: domain.VersionMatch{}},
+		{name: "weak and strong tags", header: `W/"3", "4"`, want: domain.VersionMatch{4}},
+		{name: "foreign tag", header: `"abc"`, want: domain.VersionMatch{}},
+		{name: "comma inside a tag", header: `"a,b", "2"`, want: domain.VersionMatch{2}},
+		{name: "unquoted", header: "3", wantErr: true},
+		{name: "unterminated", header: `"3`, wantErr: true},
+		{name: "missing comma", header: `"3" "4"`, wantErr: true},
+		{name: "any in a list", header: `*, "3"`, wantErr: true},
+		{name: "lowercase weak prefix", header: `w/"3"`, wantErr: true},
+		{name: "only commas", header: ",,", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestContext(ifMatchHeader, tt.header)
+
+			got, err := getIfMatchVersion(c)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error: %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if (got == nil) != (tt.want == nil) || !slices.Equal(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		match   domain.VersionMatch
+		version int
+		want    bool
+	}{
+		{name: "no precondition", match: nil, version: 3, want: true},
+		{name: "matching version", match: domain.VersionMatch{3}, version: 3, want: true},
+		{name: "one of several", match: domain.VersionMatch{1, 3}, version: 3, want: true},
+		{name: "other version", match: domain.VersionMatch{2}, version: 3, want: false},
+		{name: "weak tags only", match: domain.VersionMatch{}, version: 3, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.match.Matches(tt.version); got != tt.want {
+				t.Errorf("Matches(%d) = %v, want %v", tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{name: "absent", header: "", want: false},
+		{name: "same version", header: `"3"`, want: true},
+		{name: "weak tag", header: `W/"3"`, want: true},
+		{name: "in a list", header: `"1", W/"3"`, want: true},
+		{name: "other version", header: `"2"`, want: false},
+		{name: "any", header: "*", want: true},
+		{name: "invalid", header: "3", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestContext(ifNoneMatchHeader, tt.header)
+
+			if got := notModified(c, 3); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+
+			if etag := c.Writer.Header().Get(etagHeader); etag != `"3"` {
+				t.Errorf("ETag = %s, want \"3\"", etag)
+			}
+		})
+	}
+}
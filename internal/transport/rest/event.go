@@ -0,0 +1,259 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/pkg/httputil"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	lastEventIdHeader = "Last-Event-ID"
+
+	eventTypeChange = "change"
+	// eventTypeReset tells the client it missed too many events to resume
+	// and should reload its lists.
+	eventTypeReset = "reset"
+
+	// eventsSubprotocol is the WebSocket subprotocol of the event stream.
+	// Browsers pass their token as a second "bearer.<token>" subprotocol,
+	// which the server never selects.
+	eventsSubprotocol      = "events"
+	tokenSubprotocolPrefix = "bearer."
+
+	streamHeartbeat    = 30 * time.Second
+	streamWriteTimeout = 10 * time.Second
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	Subprotocols:    []string{eventsSubprotocol},
+}
+
+type streamMessage struct {
+	Type  string              `json:"type"`
+	Event *domain.ChangeEvent `json:"event,omitempty"`
+}
+
+// eventStream is a subscription to the user's change events, starting with
+// the events missed since the client's last event id.
+type eventStream struct {
+	events      <-chan domain.ChangeEvent
+	unsubscribe func()
+	replay      []domain.ChangeEvent
+	reset       bool
+	replayed    map[int]bool
+}
+
+func (h *Handler) openEventStream(c *gin.Context) (*eventStream, bool) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return nil, false
+	}
+
+	lastEventId, err := getLastEventId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return nil, false
+	}
+
+	// Subscribe before replaying, so no event falls between the two.
+	stream := &eventStream{replayed: make(map[int]bool)}
+	stream.events, stream.unsubscribe = h.EventService.Subscribe(userId)
+
+	if lastEventId > 0 {
+		stream.replay, stream.reset, err = h.EventService.Replay(c.Request.Context(), userId, lastEventId)
+		if err != nil {
+			stream.unsubscribe()
+			httputil.NewError(c, http.StatusInternalServerError, err)
+			return nil, false
+		}
+	}
+
+	// A client that missed too many events reloads instead of replaying them.
+	if stream.reset {
+		stream.replay = nil
+	}
+
+	for _, event := range stream.replay {
+		stream.replayed[event.Id] = true
+	}
+
+	return stream, true
+}
+
+// @Summary Event Stream
+// @Description Stream changes to the lists and items the user can access as Server-Sent Events. Reconnecting clients resume after the event in the Last-Event-ID header, a reset event means too many were missed and the client should reload
+// @Security ApiKeyAuth
+// @Tags events
+// @Produce text/event-stream
+// @Param Last-Event-ID header int false "Id of the last event received"
+// @Param last_event_id query int false "Id of the last event received"
+// @Success 200 {object} domain.ChangeEvent
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/events [get]
+func (h *Handler) streamEvents(c *gin.Context) {
+	stream, ok := h.openEventStream(c)
+	if !ok {
+		return
+	}
+	defer stream.unsubscribe()
+
+	// The stream outlives the server's write timeout.
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	if stream.reset {
+		c.Render(-1, sse.Event{Event: eventTypeReset, Data: ""})
+	}
+
+	for _, event := range stream.replay {
+		c.Render(-1, sse.Event{Id: strconv.Itoa(event.Id), Event: eventTypeChange, Data: event})
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			c.Writer.WriteString(": ping\n\n")
+		case event, ok := <-stream.events:
+			if !ok {
+				return
+			}
+
+			if stream.replayed[event.Id] {
+				continue
+			}
+
+			c.Render(-1, sse.Event{Id: strconv.Itoa(event.Id), Event: eventTypeChange, Data: event})
+		}
+
+		c.Writer.Flush()
+	}
+}
+
+// @Summary Event WebSocket
+// @Description Stream changes to the lists and items the user can access over a WebSocket. Messages are {"type": "change", "event": {...}} or {"type": "reset"}, resuming works as for the Server-Sent Events stream. Browsers, which can't set the Authorization header, open the socket with the subprotocols "events" and "bearer.<token>"
+// @Security ApiKeyAuth
+// @Tags events
+// @Param Last-Event-ID header int false "Id of the last event received"
+// @Param last_event_id query int false "Id of the last event received"
+// @Param Sec-WebSocket-Protocol header string false "events, bearer.<token>"
+// @Success 101 {object} domain.ChangeEvent
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/events/ws [get]
+func (h *Handler) eventsWebSocket(c *gin.Context) {
+	stream, ok := h.openEventStream(c)
+	if !ok {
+		return
+	}
+	defer stream.unsubscribe()
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	// Clients only send control frames, reading them notices when they leave.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+
+		conn.SetReadLimit(512)
+		conn.SetReadDeadline(time.Now().Add(2 * streamHeartbeat))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(2 * streamHeartbeat))
+		})
+
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	write := func(message streamMessage) error {
+		conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		return conn.WriteJSON(message)
+	}
+
+	if stream.reset {
+		if err := write(streamMessage{Type: eventTypeReset}); err != nil {
+			return
+		}
+	}
+
+	for i := range stream.replay {
+		if err := write(streamMessage{Type: eventTypeChange, Event: &stream.replay[i]}); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-heartbeat.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout))
+		case event, ok := <-stream.events:
+			if !ok {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too slow"),
+					time.Now().Add(streamWriteTimeout))
+				return
+			}
+
+			if stream.replayed[event.Id] {
+				continue
+			}
+
+			err = write(streamMessage{Type: eventTypeChange, Event: &event})
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+func getLastEventId(c *gin.Context) (int, error) {
+	value := c.GetHeader(lastEventIdHeader)
+	if value == "" {
+		value = c.Query("last_event_id")
+	}
+
+	if value == "" {
+		return 0, nil
+	}
+
+	eventId, err := strconv.Atoi(value)
+	if err != nil || eventId < 0 {
+		return 0, errors.New("invalid last event id")
+	}
+
+	return eventId, nil
+}
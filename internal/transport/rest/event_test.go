@@ -0,0 +1,128 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+type fakeEvents struct {
+	replay []domain.ChangeEvent
+	reset  bool
+}
+
+func (f *fakeEvents) Subscribe(userId int) (<-chan domain.ChangeEvent, func()) {
+	return make(chan domain.ChangeEvent), func() {}
+}
+
+func (f *fakeEvents) Replay(ctx context.Context, userId, eventId int) ([]domain.ChangeEvent, bool, error) {
+	return f.replay, f.reset, nil
+}
+
+func TestGetTokenFromRequest(t *testing.T) {
+	tests := []struct {
+		name         string
+		header       string
+		upgrade      bool
+		subprotocols string
+		want         string
+		wantErr      bool
+	}{
+		{name: "bearer header", header: "Bearer abc", want: "abc"},
+		{name: "no header", wantErr: true},
+		{name: "basic header", header: "Basic abc", wantErr: true},
+		{name: "websocket header", header: "Bearer abc", upgrade: true, want: "abc"},
+		{name: "websocket subprotocol", upgrade: true, subprotocols: "events, bearer.abc", want: "abc"},
+		{name: "websocket without token", upgrade: true, subprotocols: "events", wantErr: true},
+		{name: "websocket empty token", upgrade: true, subprotocols: "events, bearer.", wantErr: true},
+		{name: "subprotocol without upgrade", subprotocols: "events, bearer.abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestContext(authorizationHeader, tt.header)
+			if tt.upgrade {
+				c.Request.Header.Set("Connection", "Upgrade")
+				c.Request.Header.Set("Upgrade", "websocket")
+			}
+			if tt.subprotocols != "" {
+				c.Request.Header.Set("Sec-WebSocket-Protocol", tt.subprotocols)
+			}
+
+			got, err := getTokenFromRequest(c)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error: %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventsWebSocketReplay(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	replay := []domain.ChangeEvent{{Id: 4}, {Id: 5}}
+
+	tests := []struct {
+		name  string
+		reset bool
+		want  []string
+	}{
+		{name: "replay", want: []string{eventTypeChange, eventTypeChange}},
+		{name: "reset", reset: true, want: []string{eventTypeReset}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{EventService: &fakeEvents{replay: replay, reset: tt.reset}}
+
+			router := gin.New()
+			router.GET("/events/ws", func(c *gin.Context) { c.Set(userCtx, 1) }, h.eventsWebSocket)
+
+			server := httptest.NewServer(router)
+			defer server.Close()
+
+			dialer := websocket.Dialer{Subprotocols: []string{eventsSubprotocol, tokenSubprotocolPrefix + "abc"}}
+			url := "ws" + strings.TrimPrefix(server.URL, "http") + "/events/ws?last_event_id=3"
+
+			conn, resp, err := dialer.Dial(url, nil)
+			if err != nil {
+				t.Fatalf("dial: %v", err)
+			}
+			defer conn.Close()
+
+			if resp.StatusCode != http.StatusSwitchingProtocols || conn.Subprotocol() != eventsSubprotocol {
+				t.Fatalf("got %d with subprotocol %q, want 101 with %q",
+					resp.StatusCode, conn.Subprotocol(), eventsSubprotocol)
+			}
+
+			for _, want := range tt.want {
+				var message streamMessage
+				if err := conn.ReadJSON(&message); err != nil {
+					t.Fatalf("read: %v", err)
+				}
+
+				if message.Type != want {
+					t.Errorf("got a %q message, want %q", message.Type, want)
+				}
+			}
+
+			// Nothing but the expected messages arrives.
+			conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+			var extra streamMessage
+			if err := conn.ReadJSON(&extra); err == nil {
+				t.Errorf("got an unexpected %q message", extra.Type)
+			}
+		})
+	}
+}
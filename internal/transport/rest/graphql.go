@@ -0,0 +1,38 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/SavelyDev/crud-app/pkg/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary GraphQL
+// @Description Run a GraphQL query or mutation, the body is {"query": "...", "operationName": "...", "variables": {...}}. Users, lists and items and their relations are resolved in batches per query
+// @Security ApiKeyAuth
+// @Tags graphql
+// @Accept json
+// @Produce json
+// @Success 200 {object} object
+// @Failure 400 {object} object
+// @Failure 401 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /graphql [post]
+func (h *Handler) graphqlQuery(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.GraphQLService.ServeQuery(c.Writer, c.Request, userId)
+}
+
+// @Summary GraphQL Subscriptions
+// @Description Run GraphQL subscriptions, queries and mutations over a WebSocket using the graphql-transport-ws protocol. The access token is sent as {"Authorization": "Bearer ..."} in the connection_init payload, or in the Authorization header
+// @Tags graphql
+// @Success 101
+// @Router /graphql [get]
+func (h *Handler) graphqlSubscriptions(c *gin.Context) {
+	h.GraphQLService.ServeWebSocket(c.Writer, c.Request)
+}
@@ -1,88 +1,322 @@
 package rest
 
 import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
 	"github.com/SavelyDev/crud-app/internal/domain"
 	"github.com/gin-gonic/gin"
-
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
-
-	_ "github.com/SavelyDev/crud-app/docs"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 type Auth interface {
-	SignUp(user domain.User) (int, error)
-	SignIn(user domain.SignInInput) (string, string, error)
+	SignUp(ctx context.Context, user domain.User) (int, error)
+	SignIn(ctx context.Context, user domain.SignInInput) (string, string, error)
 	ParseToken(accesToken string) (int, error)
-	RefreshToken(refreshToken string) (string, string, error)
+	RefreshToken(ctx context.Context, refreshToken string) (string, string, error)
+	IsAdmin(ctx context.Context, userId int) (bool, error)
 }
 
 type TodoList interface {
-	CreateList(userId int, todoList domain.TodoList) (int, error)
-	GetAllLists(userId int) ([]domain.TodoList, error)
-	GetListById(userId, listId int) (domain.TodoList, error)
-	DeleteList(userId, listId int) error
-	UpdateList(userId, listId int, input domain.UpdateListInput) error
+	CreateList(ctx context.Context, userId int, todoList domain.TodoList) (int, error)
+	GetAllLists(ctx context.Context, userId int, withArchived bool) ([]domain.TodoList, error)
+	GetListById(ctx context.Context, userId, listId int) (domain.TodoList, error)
+	DeleteList(ctx context.Context, userId, listId int) error
+	SetArchived(ctx context.Context, userId, listId int, archived bool) error
+	ReplaceList(ctx context.Context, userId, listId int, input domain.ReplaceListInput) error
+	PatchList(ctx context.Context, userId, listId int, patch domain.Patch) error
+	GetTemplates(ctx context.Context, userId int) ([]domain.TodoList, error)
+	DuplicateList(ctx context.Context, userId, listId int, input domain.DuplicateListInput) (int, error)
+	CreateFromTemplate(ctx context.Context, userId, templateId int, input domain.FromTemplateInput) (int, error)
+	AddMember(ctx context.Context, userId, listId int, input domain.AddMemberInput) error
+	GetMembers(ctx context.Context, userId, listId int) ([]domain.ListMember, error)
+	RemoveMember(ctx context.Context, userId, listId, memberId int) error
 }
 
 type TodoItem interface {
-	CreateItem(userId, listId int, input domain.TodoItem) (int, error)
-	GetAllItems(userId, listId int) ([]domain.TodoItem, error)
-	GetItemById(userId, itemId int) (domain.TodoItem, error)
-	DeleteItem(userId, itemId int) error
-	UpdateItem(userId, itemId int, input domain.UpdateItemInput) error
+	CreateItem(ctx context.Context, userId, listId int, input domain.TodoItem) (int, error)
+	GetAllItems(ctx context.Context, userId, listId int) ([]domain.TodoItem, error)
+	GetItemById(ctx context.Context, userId, itemId int) (domain.TodoItem, error)
+	DeleteItem(ctx context.Context, userId, itemId int) error
+	ReplaceItem(ctx context.Context, userId, itemId int, input domain.ReplaceItemInput) error
+	PatchItem(ctx context.Context, userId, itemId int, patch domain.Patch) error
+	ApplyBulk(ctx context.Context, userId int, input domain.BulkInput) (domain.BulkResponse, error)
+	MoveItem(ctx context.Context, userId, itemId int, input domain.MoveItemInput) error
+	MoveItems(ctx context.Context, userId int, input domain.MoveItemsInput) error
+	CopyItem(ctx context.Context, userId, itemId int, input domain.MoveItemInput) (int, error)
+}
+
+type Trash interface {
+	GetTrash(ctx context.Context, userId int) ([]domain.TrashEntry, error)
+	Restore(ctx context.Context, userId int, entityType string, id int) error
+}
+
+type Search interface {
+	Search(ctx context.Context, userId int, input domain.SearchInput) ([]domain.SearchHit, error)
+}
+
+type Comment interface {
+	CreateComment(ctx context.Context, userId, itemId int, input domain.CommentInput) (int, error)
+	GetComments(ctx context.Context, userId, itemId int, page domain.Pagination) ([]domain.Comment, error)
+	UpdateComment(ctx context.Context, userId, commentId int, input domain.CommentInput) error
+	DeleteComment(ctx context.Context, userId, commentId int) error
+}
+
+type Notification interface {
+	GetNotifications(ctx context.Context, userId int, page domain.Pagination) ([]domain.Notification, error)
+	MarkRead(ctx context.Context, userId, notificationId int) error
+}
+
+type Attachment interface {
+	MaxSize() int64
+	CreateAttachment(ctx context.Context, userId, itemId int, fileName string,
+		size int64, file io.Reader) (domain.Attachment, error)
+	GetAttachments(ctx context.Context, userId, itemId int) ([]domain.Attachment, error)
+	DeleteAttachment(ctx context.Context, userId, attachmentId int) error
+	OpenAttachment(ctx context.Context, attachmentId int, expires int64,
+		signature string) (domain.Attachment, io.ReadCloser, error)
+}
+
+type Audit interface {
+	GetListHistory(ctx context.Context, userId, listId int, page domain.Pagination) ([]domain.AuditEvent, error)
+	GetItemHistory(ctx context.Context, userId, itemId int, page domain.Pagination) ([]domain.AuditEvent, error)
+	GetEvents(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEvent, error)
+	Undo(ctx context.Context, userId, eventId int) error
+}
+
+type Idempotency interface {
+	Begin(ctx context.Context, userId int, key, fingerprint string) (*domain.StoredResponse, error)
+	Complete(ctx context.Context, userId int, key string, response domain.StoredResponse) error
+	Release(ctx context.Context, userId int, key string) error
+}
+
+type Event interface {
+	Subscribe(userId int) (<-chan domain.ChangeEvent, func())
+	Replay(ctx context.Context, userId, eventId int) ([]domain.ChangeEvent, bool, error)
+}
+
+type Webhook interface {
+	CreateWebhook(ctx context.Context, userId int, input domain.WebhookInput) (int, string, error)
+	GetWebhooks(ctx context.Context, userId int) ([]domain.Webhook, error)
+	GetWebhookById(ctx context.Context, userId, webhookId int) (domain.Webhook, error)
+	UpdateWebhook(ctx context.Context, userId, webhookId int, input domain.WebhookInput) error
+	DeleteWebhook(ctx context.Context, userId, webhookId int) error
+	SendTestEvent(ctx context.Context, userId, webhookId int) (int, error)
+	GetDeliveries(ctx context.Context, userId, webhookId int, page domain.Pagination) ([]domain.WebhookDelivery, error)
+}
+
+type Sync interface {
+	GetChanges(ctx context.Context, userId int, since string, limit int) (domain.SyncChanges, error)
+	ApplyChanges(ctx context.Context, userId int, input domain.SyncInput) ([]domain.SyncResult, error)
+}
+
+type RateLimit interface {
+	Take(ctx context.Context, group, client string) (domain.RateLimitResult, bool, error)
+}
+
+type Health interface {
+	Ready(ctx context.Context) domain.HealthReport
+}
+
+type GraphQL interface {
+	ServeQuery(w http.ResponseWriter, r *http.Request, userId int)
+	ServeWebSocket(w http.ResponseWriter, r *http.Request)
 }
 
 type Handler struct {
-	AuthService     Auth
-	TodoListService TodoList
-	TodoItemService TodoItem
+	AuthService         Auth
+	TodoListService     TodoList
+	TodoItemService     TodoItem
+	TrashService        Trash
+	SearchService       Search
+	CommentService      Comment
+	NotificationService Notification
+	AttachmentService   Attachment
+	AuditService        Audit
+	IdempotencyService  Idempotency
+	EventService        Event
+	WebhookService      Webhook
+	SyncService         Sync
+	GraphQLService      GraphQL
+	RateLimitService    RateLimit
+	Versioning          Versioning
+	CORS                CORS
+	Security            SecurityHeaders
+	// TrustedProxies are the addresses or CIDRs whose X-Forwarded-For
+	// header is believed for the client IP, none by default.
+	TrustedProxies []string
+	// DBTimeout bounds the database work of a request, 0 leaves it
+	// unbounded.
+	DBTimeout     time.Duration
+	HealthService Health
 }
 
-func NewHandler(auth Auth, todoList TodoList, todoItem TodoItem) *Handler {
+func NewHandler(auth Auth, todoList TodoList, todoItem TodoItem, trash Trash, search Search,
+	comment Comment, notification Notification, attachment Attachment, audit Audit,
+	idempotency Idempotency, event Event, webhook Webhook, sync Sync, graphql GraphQL, rateLimit RateLimit, versioning Versioning, cors CORS,
+	security SecurityHeaders, trustedProxies []string, dbTimeout time.Duration, health Health) *Handler {
 	return &Handler{AuthService: auth,
-		TodoListService: todoList,
-		TodoItemService: todoItem,
+		TodoListService:     todoList,
+		TodoItemService:     todoItem,
+		TrashService:        trash,
+		SearchService:       search,
+		CommentService:      comment,
+		NotificationService: notification,
+		AttachmentService:   attachment,
+		AuditService:        audit,
+		IdempotencyService:  idempotency,
+		EventService:        event,
+		WebhookService:      webhook,
+		SyncService:         sync,
+		GraphQLService:      graphql,
+		RateLimitService:    rateLimit,
+		Versioning:          versioning,
+		CORS:                cors,
+		Security:            security,
+		TrustedProxies:      trustedProxies,
+		DBTimeout:           dbTimeout,
+		HealthService:       health,
 	}
 }
 
 func (h *Handler) InitRouter() *gin.Engine {
 	router := gin.New()
-	router.Use(h.loggingMiddleware)
 
-	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	// Rate limits and logs key on the client IP, which clients could pick
+	// themselves if every proxy header was believed. The config validates
+	// the proxies, so this can't fail.
+	if err := router.SetTrustedProxies(h.TrustedProxies); err != nil {
+		panic(err)
+	}
+
+	// Probes are registered ahead of the middlewares, they hit every
+	// instance every few seconds and would drown the logs, traces and
+	// metrics.
+	router.GET("/healthz", h.healthz)
+	router.GET("/readyz", h.readyz)
+
+	router.Use(otelgin.Middleware(serviceName), h.requestId, h.accessLog, h.instrument, h.recovery, h.corsMiddleware(), h.securityHeaders(h.Security.ContentSecurityPolicy))
 
-	auth := router.Group("/auth")
+	router.GET("/swagger/*any", h.securityHeaders(h.Security.SwaggerContentSecurityPolicy), h.swagger)
+
+	auth := router.Group("/auth", h.rateLimit("auth"), h.dbTimeout)
 	{
 		auth.POST("/sign-up", h.signUp)
 		auth.GET("/sign-in", h.signIn)
 		auth.GET("/refresh", h.refresh)
 	}
 
-	api := router.Group("/api", h.userIdentity)
+	router.GET("/attachments/:id/download", h.rateLimit("downloads"), h.downloadAttachment)
+
+	router.POST("/graphql", h.userIdentity, h.rateLimit("graphql"), h.dbTimeout, h.graphqlQuery)
+	router.GET("/graphql", h.rateLimit("graphql"), h.graphqlSubscriptions)
+
+	h.initAPI(router.Group("/api", h.negotiateVersion, h.userIdentity, h.rateLimit("api")))
+	h.initAPI(router.Group("/api/v1", h.apiVersion(apiV1), h.userIdentity, h.rateLimit("api")))
+	h.initAPI(router.Group("/api/v2", h.apiVersion(apiV2), h.userIdentity, h.rateLimit("api")))
+
+	return router
+}
+
+// initAPI registers the API routes, served unversioned under /api, where
+// the Accept header picks the version, and under /api/v1 and /api/v2.
+func (h *Handler) initAPI(api *gin.RouterGroup) {
+	// Streams stay open for as long as the client is connected, so only
+	// the other routes are bounded by the database timeout.
+	events := api.Group("/events")
+	{
+		events.GET("", h.streamEvents)
+		events.GET("/ws", h.eventsWebSocket)
+	}
+
+	api = api.Group("", h.dbTimeout)
+
+	lists := api.Group("/lists")
 	{
-		lists := api.Group("/lists")
-		{
-			lists.POST("/", h.createList)
-			lists.GET("/", h.getAllLists)
-			lists.GET("/:id", h.getListById)
-			lists.PUT("/:id", h.updateList)
-			lists.DELETE("/:id", h.deleteList)
-
-			items := lists.Group(":id/items")
-			{
-				items.POST("/", h.createItem)
-				items.GET("/", h.getAllItems)
-			}
-		}
+		lists.POST("/", h.idempotent, h.createList)
+		lists.GET("/", h.getAllLists)
+		lists.GET("/:id", h.getListById)
+		lists.PUT("/:id", h.updateList)
+		lists.PATCH("/:id", h.patchList)
+		lists.DELETE("/:id", h.deleteList)
+		lists.POST("/:id/duplicate", h.idempotent, h.duplicateList)
+		lists.POST("/:id/archive", h.archiveList)
+		lists.POST("/:id/unarchive", h.unarchiveList)
+		lists.GET("/templates", h.getTemplates)
+		lists.POST("/from-template/:id", h.idempotent, h.createFromTemplate)
+		lists.GET("/:id/history", h.getListHistory)
+		lists.GET("/:id/members", h.getListMembers)
+		lists.POST("/:id/members", h.addListMember)
+		lists.DELETE("/:id/members/:userId", h.removeListMember)
 
-		items := api.Group("items")
+		items := lists.Group(":id/items")
 		{
-			items.GET("/:id", h.getItemById)
-			items.PUT("/:id", h.updateItem)
-			items.DELETE("/:id", h.deleteItem)
+			items.POST("/", h.idempotent, h.createItem)
+			items.GET("/", h.getAllItems)
 		}
 	}
 
-	return router
+	items := api.Group("items")
+	{
+		items.GET("/:id", h.getItemById)
+		items.PUT("/:id", h.updateItem)
+		items.PATCH("/:id", h.patchItem)
+		items.DELETE("/:id", h.deleteItem)
+		items.POST("/:id/move", h.moveItem)
+		items.POST("/:id/copy", h.idempotent, h.copyItem)
+		items.POST("/move", h.moveItems)
+		items.POST("/bulk", h.idempotent, h.bulkItems)
+		items.POST("/:id/comments", h.createComment)
+		items.GET("/:id/comments", h.getComments)
+		items.POST("/:id/attachments", h.uploadAttachment)
+		items.GET("/:id/attachments", h.getAttachments)
+		items.GET("/:id/history", h.getItemHistory)
+	}
+
+	api.DELETE("/attachments/:id", h.deleteAttachment)
+
+	sync := api.Group("/sync")
+	{
+		sync.GET("", h.getChanges)
+		sync.POST("", h.idempotent, h.applyChanges)
+	}
+
+	webhooks := api.Group("/webhooks")
+	{
+		webhooks.POST("", h.createWebhook)
+		webhooks.GET("", h.getWebhooks)
+		webhooks.GET("/:id", h.getWebhookById)
+		webhooks.PUT("/:id", h.updateWebhook)
+		webhooks.DELETE("/:id", h.deleteWebhook)
+		webhooks.POST("/:id/test", h.testWebhook)
+		webhooks.GET("/:id/deliveries", h.getWebhookDeliveries)
+	}
+
+	comments := api.Group("/comments")
+	{
+		comments.PUT("/:id", h.updateComment)
+		comments.DELETE("/:id", h.deleteComment)
+	}
+
+	notifications := api.Group("/notifications")
+	{
+		notifications.GET("/", h.getNotifications)
+		notifications.POST("/:id/read", h.markNotificationRead)
+	}
+
+	trash := api.Group("/trash")
+	{
+		trash.GET("/", h.getTrash)
+		trash.POST("/:type/:id/restore", h.restoreFromTrash)
+	}
+
+	api.GET("/search", h.rateLimit("search"), h.search)
+	api.POST("/undo/:eventId", h.undo)
+
+	admin := api.Group("/admin", h.adminOnly)
+	{
+		admin.GET("/audit", h.getAuditEvents)
+	}
 }
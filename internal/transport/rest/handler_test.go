@@ -0,0 +1,45 @@
+package rest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+func TestInitRouterTrustedProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logrus.SetOutput(io.Discard)
+
+	tests := []struct {
+		name    string
+		proxies []string
+		remote  string
+		want    string
+	}{
+		{name: "no proxies", remote: "10.0.0.2:1234", want: "10.0.0.2"},
+		{name: "trusted proxy", proxies: []string{"10.0.0.0/8"}, remote: "10.0.0.2:1234", want: "203.0.113.7"},
+		{name: "untrusted proxy", proxies: []string{"10.0.0.0/8"}, remote: "192.0.2.1:1234", want: "192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := (&Handler{TrustedProxies: tt.proxies}).InitRouter()
+
+			var got string
+			router.GET("/ip", func(c *gin.Context) { got = c.ClientIP() })
+
+			req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+			req.RemoteAddr = tt.remote
+			req.Header.Set("X-Forwarded-For", "203.0.113.7")
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("got client ip %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// readinessTimeout bounds the dependency checks of a readiness probe, so
+// that a hanging database fails the probe instead of timing it out.
+const readinessTimeout = 2 * time.Second
+
+// @Summary Liveness
+// @Description Reports that the process is up, it doesn't check any dependency
+// @Tags health
+// @Produce json
+// @Success 200 {object} domain.HealthReport
+// @Router /healthz [get]
+func (h *Handler) healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, domain.HealthReport{Status: domain.HealthStatusOK})
+}
+
+// @Summary Readiness
+// @Description Checks the database, its migrations and the background jobs, fails as soon as the server starts shutting down
+// @Tags health
+// @Produce json
+// @Success 200 {object} domain.HealthReport
+// @Failure 503 {object} domain.HealthReport
+// @Router /readyz [get]
+func (h *Handler) readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	report := h.HealthService.Ready(ctx)
+	if report.Status != domain.HealthStatusOK {
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
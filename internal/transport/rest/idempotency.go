@@ -0,0 +1,125 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/pkg/httputil"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// responseRecorder keeps a copy of the response body while writing it.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotent makes a request with an Idempotency-Key header run at most once
+// per user and key: the first response is stored and replayed for retries.
+// Server errors and panics aren't stored, so those requests can be retried.
+func (h *Handler) idempotent(c *gin.Context) {
+	key := c.GetHeader(idempotencyKeyHeader)
+	if key == "" {
+		return
+	}
+
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		c.Abort()
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		c.Abort()
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	stored, err := h.IdempotencyService.Begin(c.Request.Context(), userId, key, requestFingerprint(c.Request, body))
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidIdempotencyKey):
+			httputil.NewError(c, http.StatusBadRequest, err)
+		case errors.Is(err, domain.ErrIdempotencyKeyReused):
+			httputil.NewError(c, http.StatusUnprocessableEntity, err)
+		case errors.Is(err, domain.ErrRequestInProgress):
+			httputil.NewError(c, http.StatusConflict, err)
+		default:
+			httputil.NewError(c, http.StatusInternalServerError, err)
+		}
+		c.Abort()
+		return
+	}
+
+	if stored != nil {
+		c.Header(idempotentReplayedHeader, "true")
+		c.Data(stored.StatusCode, stored.ContentType, stored.Body)
+		c.Abort()
+		return
+	}
+
+	recorder := &responseRecorder{ResponseWriter: c.Writer}
+	c.Writer = recorder
+
+	// The key is settled even when the handler panicked, the client went
+	// away or the request timed out, otherwise retries are rejected until
+	// the lease runs out.
+	defer func() {
+		rec := recover()
+		ctx := context.WithoutCancel(c.Request.Context())
+
+		var err error
+		if rec != nil || recorder.Status() >= http.StatusInternalServerError {
+			err = h.IdempotencyService.Release(ctx, userId, key)
+		} else {
+			err = h.IdempotencyService.Complete(ctx, userId, key, domain.StoredResponse{
+				StatusCode:  recorder.Status(),
+				ContentType: recorder.Header().Get("Content-Type"),
+				Body:        recorder.body.Bytes(),
+			})
+		}
+
+		if err != nil {
+			logrus.WithField("idempotency_key", key).Error(err)
+		}
+
+		if rec != nil {
+			panic(rec)
+		}
+	}()
+
+	c.Next()
+}
+
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
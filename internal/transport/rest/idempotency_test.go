@@ -0,0 +1,114 @@
+package rest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type fakeIdempotency struct {
+	completed []domain.StoredResponse
+	released  int
+}
+
+func (f *fakeIdempotency) Begin(ctx context.Context, userId int, key,
+	fingerprint string) (*domain.StoredResponse, error) {
+	return nil, nil
+}
+
+func (f *fakeIdempotency) Complete(ctx context.Context, userId int, key string,
+	response domain.StoredResponse) error {
+	f.completed = append(f.completed, response)
+	return nil
+}
+
+func (f *fakeIdempotency) Release(ctx context.Context, userId int, key string) error {
+	f.released++
+	return nil
+}
+
+func TestIdempotentSettlesKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logrus.SetOutput(io.Discard)
+
+	tests := []struct {
+		name          string
+		handler       gin.HandlerFunc
+		wantStatus    int
+		wantCompleted bool
+	}{
+		{
+			name:          "created",
+			handler:       func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"id": 1}) },
+			wantStatus:    http.StatusOK,
+			wantCompleted: true,
+		},
+		{
+			name:          "client error is stored",
+			handler:       func(c *gin.Context) { c.JSON(http.StatusBadRequest, gin.H{"message": "bad"}) },
+			wantStatus:    http.StatusBadRequest,
+			wantCompleted: true,
+		},
+		{
+			name:       "server error is released",
+			handler:    func(c *gin.Context) { c.JSON(http.StatusServiceUnavailable, gin.H{"message": "down"}) },
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "panic is released",
+			handler:    func(c *gin.Context) { panic("nil map") },
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "panic after writing is released",
+			handler: func(c *gin.Context) {
+				c.Status(http.StatusOK)
+				c.Writer.WriteHeaderNow()
+				panic("nil map")
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeIdempotency{}
+			h := &Handler{IdempotencyService: store}
+
+			router := gin.New()
+			router.Use(h.recovery)
+			router.POST("/lists", func(c *gin.Context) { c.Set("userId", 1) }, h.idempotent, tt.handler)
+
+			req := httptest.NewRequest(http.MethodPost, "/lists", strings.NewReader(`{"title":"x"}`))
+			req.Header.Set(idempotencyKeyHeader, "key-1")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status %d, want %d", rec.Code, tt.wantStatus)
+			}
+
+			if tt.wantCompleted {
+				if len(store.completed) != 1 || store.released != 0 {
+					t.Fatalf("completed %d, released %d, want the key completed", len(store.completed), store.released)
+				}
+
+				if store.completed[0].StatusCode != tt.wantStatus || store.completed[0].Body == nil {
+					t.Errorf("stored %+v, want the %d response", store.completed[0], tt.wantStatus)
+				}
+				return
+			}
+
+			if len(store.completed) != 0 || store.released != 1 {
+				t.Errorf("completed %d, released %d, want the key released", len(store.completed), store.released)
+			}
+		})
+	}
+}
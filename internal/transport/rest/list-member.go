@@ -0,0 +1,144 @@
+package rest
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/pkg/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errListNotFound   = errors.New("list not found")
+	errMemberNotFound = errors.New("list or member not found")
+)
+
+// @Summary Add List Member
+// @Description Share a todo list with a registered user, members have full access to the list. The response is the same whether or not the email is registered or already a member
+// @Security ApiKeyAuth
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Param id path int true "List ID"
+// @Param input body domain.AddMemberInput true "Email of the user to add"
+// @Success 202 {string} string "ok"
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/lists/{id}/members [post]
+func (h *Handler) addListMember(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	listId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("invalid id param"))
+		return
+	}
+
+	var input domain.AddMemberInput
+	if err := c.BindJSON(&input); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.TodoListService.AddMember(c.Request.Context(), userId, listId, input); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			httputil.NewError(c, http.StatusNotFound, errListNotFound)
+			return
+		}
+
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"status": "ok"})
+}
+
+// @Summary Get List Members
+// @Description Get the users a todo list is shared with
+// @Security ApiKeyAuth
+// @Tags lists
+// @Produce json
+// @Param id path int true "List ID"
+// @Success 200 {array} domain.ListMember
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/lists/{id}/members [get]
+func (h *Handler) getListMembers(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	listId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("invalid id param"))
+		return
+	}
+
+	members, err := h.TodoListService.GetMembers(c.Request.Context(), userId, listId)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+// @Summary Remove List Member
+// @Description Stop sharing a todo list with a user. The list owner can remove any other member, members can only remove themselves, and the owner can't be removed
+// @Security ApiKeyAuth
+// @Tags lists
+// @Produce json
+// @Param id path int true "List ID"
+// @Param userId path int true "User ID of the member"
+// @Success 200 {string} string "ok"
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 403 {object} httputil.HTTPError
+// @Failure 404 {object} httputil.HTTPError
+// @Failure 409 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/lists/{id}/members/{userId} [delete]
+func (h *Handler) removeListMember(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	listId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("invalid id param"))
+		return
+	}
+
+	memberId, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("invalid userId param"))
+		return
+	}
+
+	if err := h.TodoListService.RemoveMember(c.Request.Context(), userId, listId, memberId); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			httputil.NewError(c, http.StatusNotFound, errMemberNotFound)
+		case errors.Is(err, domain.ErrNotListOwner):
+			httputil.NewError(c, http.StatusForbidden, err)
+		case errors.Is(err, domain.ErrListOwner):
+			httputil.NewError(c, http.StatusConflict, err)
+		default:
+			httputil.NewError(c, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
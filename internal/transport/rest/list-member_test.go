@@ -0,0 +1,75 @@
+package rest
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+type fakeMembers struct {
+	TodoList
+	err error
+}
+
+func (f fakeMembers) AddMember(ctx context.Context, userId, listId int, input domain.AddMemberInput) error {
+	return f.err
+}
+
+func (f fakeMembers) RemoveMember(ctx context.Context, userId, listId, memberId int) error {
+	return f.err
+}
+
+func (f fakeMembers) DeleteList(ctx context.Context, userId, listId int) error {
+	return f.err
+}
+
+func TestListMemberErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		err        error
+		wantStatus int
+	}{
+		{name: "add", method: http.MethodPost, path: "/lists/1/members", wantStatus: http.StatusAccepted},
+		{name: "add to an inaccessible list", method: http.MethodPost, path: "/lists/1/members", err: sql.ErrNoRows,
+			wantStatus: http.StatusNotFound},
+		{name: "remove", method: http.MethodDelete, path: "/lists/1/members/2", wantStatus: http.StatusOK},
+		{name: "remove by a member", method: http.MethodDelete, path: "/lists/1/members/2", err: domain.ErrNotListOwner,
+			wantStatus: http.StatusForbidden},
+		{name: "remove the owner", method: http.MethodDelete, path: "/lists/1/members/2", err: domain.ErrListOwner,
+			wantStatus: http.StatusConflict},
+		{name: "remove a non-member", method: http.MethodDelete, path: "/lists/1/members/2", err: sql.ErrNoRows,
+			wantStatus: http.StatusNotFound},
+		{name: "delete by a member", method: http.MethodDelete, path: "/lists/1", err: domain.ErrNotListOwner,
+			wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{TodoListService: fakeMembers{err: tt.err}}
+
+			router := gin.New()
+			lists := router.Group("/lists", func(c *gin.Context) { c.Set(userCtx, 1) })
+			lists.POST("/:id/members", h.addListMember)
+			lists.DELETE("/:id/members/:userId", h.removeListMember)
+			lists.DELETE("/:id", h.deleteList)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{"email":"bob@example.com"}`))
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package rest
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/pkg/httputil"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	requestIdHeader = "X-Request-ID"
+
+	// serviceName names the server in the request spans.
+	serviceName = "crud-app"
+)
+
+// validRequestId limits the request ids taken from clients to what is safe
+// to log and echo back.
+var validRequestId = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,64}$`)
+
+var errInternal = errors.New("internal server error")
+
+// requestId propagates the client's X-Request-ID, or generates one, and
+// returns it with the response.
+func (h *Handler) requestId(c *gin.Context) {
+	id := c.GetHeader(requestIdHeader)
+	if !validRequestId.MatchString(id) {
+		id = uuid.NewString()
+	}
+
+	c.Set(httputil.RequestIdCtx, id)
+	c.Header(requestIdHeader, id)
+
+	c.Request = c.Request.WithContext(domain.WithRequestId(c.Request.Context(), id))
+}
+
+// accessLog logs the request once it's handled.
+func (h *Handler) accessLog(c *gin.Context) {
+	start := time.Now()
+
+	c.Next()
+
+	status := c.Writer.Status()
+	fields := logrus.Fields{
+		"request_id": c.GetString(httputil.RequestIdCtx),
+		"method":     c.Request.Method,
+		"route":      c.FullPath(),
+		"uri":        c.Request.URL.RequestURI(),
+		"status":     status,
+		"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+		"bytes":      max(c.Writer.Size(), 0),
+		"client_ip":  c.ClientIP(),
+		"user_agent": c.Request.UserAgent(),
+	}
+
+	if userId, err := getUserId(c); err == nil {
+		fields["user_id"] = userId
+	}
+
+	if len(c.Errors) > 0 {
+		fields["errors"] = c.Errors.Errors()
+	}
+
+	addTraceFields(c, fields)
+
+	entry := logrus.WithFields(fields)
+	switch {
+	case status >= http.StatusInternalServerError:
+		entry.Error("request failed")
+	case status >= http.StatusBadRequest:
+		entry.Warn("request rejected")
+	default:
+		entry.Info("request handled")
+	}
+}
+
+// recovery turns a panic in a handler into a 500 and logs it with its
+// stack.
+func (h *Handler) recovery(c *gin.Context) {
+	// Version middlewares replace the writer, the error is written to the
+	// original one as they didn't get to finish their response.
+	writer := c.Writer
+
+	defer func() {
+		rec := recover()
+		if rec == nil {
+			return
+		}
+
+		if brokenPipe(rec) {
+			logrus.WithField("request_id", c.GetString(httputil.RequestIdCtx)).Debug(rec)
+			c.Abort()
+			return
+		}
+
+		fields := logrus.Fields{
+			"request_id": c.GetString(httputil.RequestIdCtx),
+			"panic":      rec,
+			"stack":      string(debug.Stack()),
+		}
+		addTraceFields(c, fields)
+
+		logrus.WithFields(fields).Error("panic recovered")
+
+		c.Writer = writer
+		if !writer.Written() {
+			if getApiVersion(c) == apiV2 {
+				c.JSON(http.StatusInternalServerError, Envelope{Error: newErrorBody(c, http.StatusInternalServerError, errInternal.Error())})
+			} else {
+				httputil.NewError(c, http.StatusInternalServerError, errInternal)
+			}
+		}
+
+		c.Abort()
+	}()
+
+	c.Next()
+}
+
+// addTraceFields adds the ids of the request's trace, so that its logs can
+// be found from the trace and the other way round.
+func addTraceFields(c *gin.Context, fields logrus.Fields) {
+	span := trace.SpanContextFromContext(c.Request.Context())
+	if !span.IsValid() {
+		return
+	}
+
+	fields["trace_id"] = span.TraceID().String()
+	fields["span_id"] = span.SpanID().String()
+}
+
+// brokenPipe reports whether the panic was caused by the client going
+// away, which isn't worth a stack trace.
+func brokenPipe(rec interface{}) bool {
+	err, ok := rec.(error)
+	if !ok {
+		return false
+	}
+
+	if errors.Is(err, http.ErrAbortHandler) {
+		return true
+	}
+
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+
+	var syscallErr *os.SyscallError
+	if !errors.As(opErr, &syscallErr) {
+		return false
+	}
+
+	msg := strings.ToLower(syscallErr.Error())
+	return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset by peer")
+}
@@ -0,0 +1,22 @@
+package rest
+
+import (
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// instrument records the request count and latency by route template.
+func (h *Handler) instrument(c *gin.Context) {
+	start := time.Now()
+
+	c.Next()
+
+	route := c.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+
+	metrics.ObserveHTTP(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+}
@@ -1,13 +1,16 @@
 package rest
 
 import (
+	"context"
 	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 
+	"github.com/SavelyDev/crud-app/internal/domain"
 	"github.com/SavelyDev/crud-app/pkg/httputil"
 	"github.com/gin-gonic/gin"
-	"github.com/sirupsen/logrus"
+	"github.com/gorilla/websocket"
 )
 
 const (
@@ -15,31 +18,75 @@ const (
 	userCtx             = "userId"
 )
 
-func (h *Handler) loggingMiddleware(c *gin.Context) {
-	logrus.WithFields(logrus.Fields{
-		"method": c.Request.Method,
-		"uri":    c.Request.URL,
-	}).Info()
-}
-
 func (h *Handler) userIdentity(c *gin.Context) {
 	token, err := getTokenFromRequest(c)
 	if err != nil {
 		httputil.NewError(c, http.StatusUnauthorized, err)
+		c.Abort()
 		return
 	}
 
 	userId, err := h.AuthService.ParseToken(token)
 	if err != nil {
 		httputil.NewError(c, http.StatusUnauthorized, err)
+		c.Abort()
 		return
 	}
 
 	c.Set(userCtx, userId)
 }
 
+func (h *Handler) adminOnly(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusUnauthorized, err)
+		c.Abort()
+		return
+	}
+
+	isAdmin, err := h.AuthService.IsAdmin(c.Request.Context(), userId)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		c.Abort()
+		return
+	}
+
+	if !isAdmin {
+		httputil.NewError(c, http.StatusForbidden, errors.New("admin access required"))
+		c.Abort()
+		return
+	}
+}
+
+// dbTimeout bounds the database work of the request by DBTimeout. It runs
+// after userIdentity, which doesn't query the database, and rateLimit,
+// which bounds its own query, so that streams can skip it.
+func (h *Handler) dbTimeout(c *gin.Context) {
+	if h.DBTimeout <= 0 {
+		return
+	}
+
+	ctx, cancel := h.withDBTimeout(c.Request.Context())
+	defer cancel()
+
+	c.Request = c.Request.WithContext(ctx)
+	c.Next()
+}
+
+func (h *Handler) withDBTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if h.DBTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, h.DBTimeout)
+}
+
 func getTokenFromRequest(c *gin.Context) (string, error) {
 	header := c.GetHeader(authorizationHeader)
+	if header == "" && websocket.IsWebSocketUpgrade(c.Request) {
+		return getTokenFromSubprotocols(c)
+	}
+
 	if header == "" {
 		return "", errors.New("empty auth header")
 	}
@@ -57,6 +104,23 @@ func getTokenFromRequest(c *gin.Context) (string, error) {
 	return headerParts[1], nil
 }
 
+// getTokenFromSubprotocols reads the token of a WebSocket handshake from a
+// "bearer.<token>" subprotocol, as browsers can't set the Authorization
+// header on WebSocket requests.
+func getTokenFromSubprotocols(c *gin.Context) (string, error) {
+	for _, protocol := range websocket.Subprotocols(c.Request) {
+		if token, ok := strings.CutPrefix(protocol, tokenSubprotocolPrefix); ok {
+			if token == "" {
+				return "", errors.New("token is empty")
+			}
+
+			return token, nil
+		}
+	}
+
+	return "", errors.New("empty auth header")
+}
+
 func getUserId(c *gin.Context) (int, error) {
 	userId, ok := c.Get("userId")
 	if !ok {
@@ -70,3 +134,30 @@ func getUserId(c *gin.Context) (int, error) {
 
 	return userIdInt, nil
 }
+
+// getPagination reads limit and offset, or page and per_page in v2.
+func getPagination(c *gin.Context) (domain.Pagination, error) {
+	if getApiVersion(c) == apiV2 {
+		c.Set(pagedCtx, true)
+		return getV2Pagination(c)
+	}
+
+	var page domain.Pagination
+	var err error
+
+	if limit := c.Query("limit"); limit != "" {
+		page.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			return page, errors.New("invalid limit param")
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		page.Offset, err = strconv.Atoi(offset)
+		if err != nil {
+			return page, errors.New("invalid offset param")
+		}
+	}
+
+	return page, nil
+}
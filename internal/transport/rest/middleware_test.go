@@ -0,0 +1,101 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SavelyDev/crud-app/pkg/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+type fakeAuth struct {
+	Auth
+}
+
+func (f fakeAuth) ParseToken(token string) (int, error) {
+	if token != "valid" {
+		return 0, errors.New("invalid token")
+	}
+
+	return 7, nil
+}
+
+func (f fakeAuth) IsAdmin(ctx context.Context, userId int) (bool, error) {
+	return false, nil
+}
+
+func TestUserIdentity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		header     string
+		wantStatus int
+		wantUserId int
+	}{
+		{name: "valid token", header: "Bearer valid", wantStatus: http.StatusOK, wantUserId: 7},
+		{name: "no header", wantStatus: http.StatusUnauthorized},
+		{name: "invalid header", header: "Token valid", wantStatus: http.StatusUnauthorized},
+		{name: "invalid token", header: "Bearer forged", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{AuthService: fakeAuth{}}
+
+			reached := false
+			router := gin.New()
+			router.GET("/lists", h.userIdentity, func(c *gin.Context) {
+				reached = true
+
+				userId, err := getUserId(c)
+				if err != nil {
+					httputil.NewError(c, http.StatusInternalServerError, err)
+					return
+				}
+
+				if userId != tt.wantUserId {
+					t.Errorf("got user %d, want %d", userId, tt.wantUserId)
+				}
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/lists", nil)
+			if tt.header != "" {
+				req.Header.Set(authorizationHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status %d, want %d", rec.Code, tt.wantStatus)
+			}
+
+			// A rejected request must not reach the handler.
+			if reached != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("handler reached: %v, want %v", reached, tt.wantStatus == http.StatusOK)
+			}
+		})
+	}
+}
+
+func TestAdminOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	h := &Handler{AuthService: fakeAuth{}}
+
+	reached := false
+	router := gin.New()
+	router.GET("/admin", func(c *gin.Context) { c.Set(userCtx, 7) }, h.adminOnly,
+		func(c *gin.Context) { reached = true })
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin", nil))
+
+	if rec.Code != http.StatusForbidden || reached {
+		t.Errorf("status %d, handler reached: %v, want 403 without reaching it", rec.Code, reached)
+	}
+}
@@ -0,0 +1,39 @@
+package rest
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+func getPatch(c *gin.Context) (domain.Patch, error) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return domain.Patch{}, err
+	}
+
+	version, err := getIfMatchVersion(c)
+	if err != nil {
+		return domain.Patch{}, err
+	}
+
+	return domain.Patch{ContentType: c.ContentType(), Body: body, Version: version}, nil
+}
+
+func patchErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrUnsupportedPatchType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, domain.ErrInvalidPatch):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, domain.ErrVersionMismatch):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, domain.ErrPatchConflict):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,57 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/SavelyDev/crud-app/pkg/httputil"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	rateLimitLimitHeader     = "X-RateLimit-Limit"
+	rateLimitRemainingHeader = "X-RateLimit-Remaining"
+	rateLimitResetHeader     = "X-RateLimit-Reset"
+	retryAfterHeader         = "Retry-After"
+)
+
+var errRateLimited = errors.New("too many requests, retry later")
+
+// rateLimit limits the requests to the route group per user, or per IP
+// for anonymous requests. X-RateLimit-Limit is the bucket size, and
+// X-RateLimit-Reset the seconds until it's full again. The request is let
+// through when the limiter fails.
+func (h *Handler) rateLimit(group string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := "ip:" + c.ClientIP()
+		if userId, err := getUserId(c); err == nil {
+			client = "user:" + strconv.Itoa(userId)
+		}
+
+		// Streams are limited too, but only the store query is bounded.
+		ctx, cancel := h.withDBTimeout(c.Request.Context())
+		result, limited, err := h.RateLimitService.Take(ctx, group, client)
+		cancel()
+		if err != nil {
+			logrus.WithField("group", group).Error(err)
+			return
+		}
+
+		if !limited {
+			return
+		}
+
+		c.Header(rateLimitLimitHeader, strconv.Itoa(result.Limit))
+		c.Header(rateLimitRemainingHeader, strconv.Itoa(result.Remaining))
+		c.Header(rateLimitResetHeader, strconv.Itoa(int(result.ResetAfter/time.Second)))
+
+		if !result.Allowed {
+			c.Header(retryAfterHeader, strconv.Itoa(int(result.RetryAfter/time.Second)))
+			httputil.NewError(c, http.StatusTooManyRequests, errRateLimited)
+			c.Abort()
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+type fakeRateLimit struct {
+	result   domain.RateLimitResult
+	deadline bool
+	client   string
+}
+
+func (f *fakeRateLimit) Take(ctx context.Context, group, client string) (domain.RateLimitResult, bool, error) {
+	_, f.deadline = ctx.Deadline()
+	f.client = client
+	return f.result, true, nil
+}
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		result       domain.RateLimitResult
+		dbTimeout    time.Duration
+		wantStatus   int
+		wantDeadline bool
+		wantHeaders  map[string]string
+	}{
+		{
+			name:         "allowed",
+			result:       domain.RateLimitResult{Allowed: true, Limit: 10, Remaining: 9, ResetAfter: 2 * time.Second},
+			dbTimeout:    time.Second,
+			wantStatus:   http.StatusOK,
+			wantDeadline: true,
+			wantHeaders:  map[string]string{rateLimitLimitHeader: "10", rateLimitRemainingHeader: "9", rateLimitResetHeader: "2"},
+		},
+		{
+			name:       "limited",
+			result:     domain.RateLimitResult{Limit: 10, RetryAfter: 3 * time.Second, ResetAfter: 20 * time.Second},
+			wantStatus: http.StatusTooManyRequests,
+			wantHeaders: map[string]string{rateLimitRemainingHeader: "0", rateLimitResetHeader: "20",
+				retryAfterHeader: "3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := &fakeRateLimit{result: tt.result}
+			h := &Handler{RateLimitService: limiter, DBTimeout: tt.dbTimeout}
+
+			router := gin.New()
+			router.GET("/lists", h.rateLimit("api"), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/lists", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status %d, want %d", rec.Code, tt.wantStatus)
+			}
+
+			if limiter.deadline != tt.wantDeadline {
+				t.Errorf("store query had a deadline: %v, want %v", limiter.deadline, tt.wantDeadline)
+			}
+
+			if limiter.client != "ip:192.0.2.1" {
+				t.Errorf("limited client %q, want ip:192.0.2.1", limiter.client)
+			}
+
+			for header, want := range tt.wantHeaders {
+				if got := rec.Header().Get(header); got != want {
+					t.Errorf("%s = %q, want %q", header, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/pkg/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+// errTagFilterUnsupported rejects the tag filter instead of ignoring it,
+// so clients don't take unfiltered hits for filtered ones.
+var errTagFilterUnsupported = errors.New("filtering by tag is not supported, items have no tags")
+
+// @Summary Search
+// @Description Full-text search across the titles and descriptions of every list and item the user can access. Filtering by tag is not supported: items have no tags yet, so the tag filter of the search request is pending an amended request and a tag param is rejected
+// @Security ApiKeyAuth
+// @Tags search
+// @Produce json
+// @Param q query string true "Search query"
+// @Param list_id query int false "Only search within this list"
+// @Param done query bool false "Only return items with this done state"
+// @Param tag query string false "Not supported yet, rejected with 400"
+// @Param limit query int false "Maximum number of hits"
+// @Success 200 {array} domain.SearchHit
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/search [get]
+func (h *Handler) search(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	input := domain.SearchInput{Query: c.Query("q")}
+	if input.Query == "" {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("empty q param"))
+		return
+	}
+
+	if _, ok := c.GetQuery("tag"); ok {
+		httputil.NewError(c, http.StatusBadRequest, errTagFilterUnsupported)
+		return
+	}
+
+	if listId := c.Query("list_id"); listId != "" {
+		id, err := strconv.Atoi(listId)
+		if err != nil {
+			httputil.NewError(c, http.StatusBadRequest, errors.New("invalid list_id param"))
+			return
+		}
+
+		input.ListId = &id
+	}
+
+	if done := c.Query("done"); done != "" {
+		d, err := strconv.ParseBool(done)
+		if err != nil {
+			httputil.NewError(c, http.StatusBadRequest, errors.New("invalid done param"))
+			return
+		}
+
+		input.Done = &d
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		input.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			httputil.NewError(c, http.StatusBadRequest, errors.New("invalid limit param"))
+			return
+		}
+	}
+
+	hits, err := h.SearchService.Search(c.Request.Context(), userId, input)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, hits)
+}
@@ -0,0 +1,84 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+type fakeSearch struct {
+	input *domain.SearchInput
+}
+
+func (f *fakeSearch) Search(ctx context.Context, userId int, input domain.SearchInput) ([]domain.SearchHit, error) {
+	f.input = &input
+	return []domain.SearchHit{}, nil
+}
+
+func TestSearchParams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		check      func(t *testing.T, input domain.SearchInput)
+	}{
+		{
+			name:       "query",
+			query:      "q=milk",
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, input domain.SearchInput) {
+				if input.Query != "milk" || input.ListId != nil || input.Done != nil {
+					t.Errorf("got %+v, want only the query", input)
+				}
+			},
+		},
+		{
+			name:       "filters",
+			query:      "q=milk&list_id=3&done=false&limit=5",
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, input domain.SearchInput) {
+				if input.ListId == nil || *input.ListId != 3 || input.Done == nil || *input.Done || input.Limit != 5 {
+					t.Errorf("got %+v, want list 3, not done, limit 5", input)
+				}
+			},
+		},
+		{name: "no query", query: "list_id=3", wantStatus: http.StatusBadRequest},
+		{name: "invalid list id", query: "q=milk&list_id=x", wantStatus: http.StatusBadRequest},
+		{name: "invalid done", query: "q=milk&done=maybe", wantStatus: http.StatusBadRequest},
+		{name: "invalid limit", query: "q=milk&limit=ten", wantStatus: http.StatusBadRequest},
+		{name: "tag", query: "q=milk&tag=groceries", wantStatus: http.StatusBadRequest},
+		{name: "empty tag", query: "q=milk&tag=", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			search := &fakeSearch{}
+			h := &Handler{SearchService: search}
+
+			router := gin.New()
+			router.GET("/search", func(c *gin.Context) { c.Set(userCtx, 1) }, h.search)
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search?"+tt.query, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d", rec.Code, tt.wantStatus)
+			}
+
+			if tt.check == nil {
+				if search.input != nil {
+					t.Errorf("searched with %+v, want the request rejected", *search.input)
+				}
+				return
+			}
+
+			tt.check(t, *search.input)
+		})
+	}
+}
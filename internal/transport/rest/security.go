@@ -0,0 +1,87 @@
+package rest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// CORS configures cross-origin requests, no origin is allowed when
+// AllowedOrigins is empty.
+type CORS struct {
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	ExposedHeaders []string
+	// AllowCredentials lets browsers send the refresh token cookie.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache preflight responses.
+	MaxAge time.Duration
+}
+
+// SecurityHeaders configures the security headers set on every response.
+type SecurityHeaders struct {
+	Enabled bool
+	// HSTSMaxAge enables Strict-Transport-Security when set, which only
+	// makes sense behind HTTPS.
+	HSTSMaxAge            time.Duration
+	FrameOptions          string
+	ContentSecurityPolicy string
+	// SwaggerContentSecurityPolicy replaces ContentSecurityPolicy for the
+	// swagger UI, which needs its own scripts and styles.
+	SwaggerContentSecurityPolicy string
+}
+
+func (h *Handler) corsMiddleware() gin.HandlerFunc {
+	if len(h.CORS.AllowedOrigins) == 0 {
+		return func(c *gin.Context) {}
+	}
+
+	return cors.New(cors.Config{
+		AllowOrigins:     h.CORS.AllowedOrigins,
+		AllowMethods:     h.CORS.AllowedMethods,
+		AllowHeaders:     h.CORS.AllowedHeaders,
+		ExposeHeaders:    h.CORS.ExposedHeaders,
+		AllowCredentials: h.CORS.AllowCredentials,
+		MaxAge:           h.CORS.MaxAge,
+	})
+}
+
+// securityHeaders sets the security headers with the given
+// Content-Security-Policy.
+func (h *Handler) securityHeaders(csp string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !h.Security.Enabled {
+			return
+		}
+
+		c.Header("X-Content-Type-Options", "nosniff")
+		c.Header("Referrer-Policy", "no-referrer")
+
+		if h.Security.FrameOptions != "" {
+			c.Header("X-Frame-Options", h.Security.FrameOptions)
+		}
+
+		if csp != "" {
+			c.Header("Content-Security-Policy", csp)
+		}
+
+		if h.Security.HSTSMaxAge > 0 {
+			c.Header("Strict-Transport-Security",
+				fmt.Sprintf("max-age=%d; includeSubDomains", int(h.Security.HSTSMaxAge.Seconds())))
+		}
+	}
+}
+
+// refreshCookie is the refresh token cookie, sent cross-site when
+// credentialed CORS requests are allowed.
+func (h *Handler) refreshCookie(refreshToken string) string {
+	cookie := fmt.Sprintf("refresh-token=%s; HttpOnly", refreshToken)
+	if h.CORS.AllowCredentials {
+		cookie += "; Secure; SameSite=None"
+	}
+
+	return cookie
+}
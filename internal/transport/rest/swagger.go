@@ -0,0 +1,197 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/SavelyDev/crud-app/docs"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/swaggo/swag"
+	"golang.org/x/net/webdav"
+
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+)
+
+// The generated docs describe v1 under /api, the per-version docs are
+// derived from them: v1 moves under /api/v1, v2 under /api/v2 with the
+// envelope and pagination of v2.
+var swaggerHandlers = map[string]gin.HandlerFunc{
+	"v1": newSwaggerHandler("v1"),
+	"v2": newSwaggerHandler("v2"),
+}
+
+func init() {
+	swag.Register("v1", swaggerDoc{version: apiV1})
+	swag.Register("v2", swaggerDoc{version: apiV2})
+}
+
+// swagger serves the docs of each version under /swagger/v1 and /swagger/v2.
+func (h *Handler) swagger(c *gin.Context) {
+	version, _, _ := strings.Cut(strings.TrimPrefix(c.Param("any"), "/"), "/")
+
+	handler, ok := swaggerHandlers[version]
+	if !ok {
+		c.Redirect(http.StatusFound, "/swagger/v2/index.html")
+		return
+	}
+
+	handler(c)
+}
+
+func newSwaggerHandler(instance string) gin.HandlerFunc {
+	// Each instance needs its own file handler, the handler's prefix is
+	// set by the first request.
+	files := &webdav.Handler{FileSystem: swaggerFiles.FS, LockSystem: webdav.NewMemLS()}
+
+	return ginSwagger.WrapHandler(files, ginSwagger.InstanceName(instance))
+}
+
+type swaggerDoc struct {
+	version int
+}
+
+func (d swaggerDoc) ReadDoc() string {
+	doc, err := versionDoc(docs.SwaggerInfo.ReadDoc(), d.version)
+	if err != nil {
+		logrus.Error(err)
+		return docs.SwaggerInfo.ReadDoc()
+	}
+
+	return doc
+}
+
+type jsonObject = map[string]interface{}
+
+func versionDoc(doc string, version int) (string, error) {
+	var spec jsonObject
+	if err := json.Unmarshal([]byte(doc), &spec); err != nil {
+		return "", err
+	}
+
+	prefix := "/api/v" + strconv.Itoa(version)
+
+	info, _ := spec["info"].(jsonObject)
+	if info != nil {
+		info["version"] = strconv.Itoa(version) + ".0"
+		if version == apiV1 {
+			info["description"] = "Deprecated, see the Sunset header for its end of life and /swagger/v2 for its successor."
+		} else {
+			info["description"] = "Responses are wrapped in {data, meta} and errors in {error}, collections are paged with page and per_page."
+		}
+	}
+
+	paths, _ := spec["paths"].(jsonObject)
+	versioned := make(jsonObject, len(paths))
+	for path, item := range paths {
+		if !strings.HasPrefix(path, "/api/") {
+			versioned[path] = item
+			continue
+		}
+
+		if version == apiV2 {
+			for method, op := range item.(jsonObject) {
+				envelopeOperation(op.(jsonObject), method == "get")
+			}
+		}
+
+		versioned[prefix+strings.TrimPrefix(path, "/api")] = item
+	}
+	spec["paths"] = versioned
+
+	if version == apiV2 {
+		definitions, _ := spec["definitions"].(jsonObject)
+		if definitions == nil {
+			definitions = jsonObject{}
+			spec["definitions"] = definitions
+		}
+
+		definitions["rest.Envelope"] = jsonObject{
+			"type": "object",
+			"properties": jsonObject{
+				"data":  jsonObject{},
+				"meta":  jsonObject{"$ref": "#/definitions/rest.PageMeta"},
+				"error": jsonObject{"$ref": "#/definitions/rest.ErrorBody"},
+			},
+		}
+		definitions["rest.PageMeta"] = jsonObject{
+			"type": "object",
+			"properties": jsonObject{
+				"page":     jsonObject{"type": "integer"},
+				"per_page": jsonObject{"type": "integer"},
+				"total":    jsonObject{"type": "integer"},
+			},
+		}
+		definitions["rest.ErrorBody"] = jsonObject{
+			"type": "object",
+			"properties": jsonObject{
+				"status":     jsonObject{"type": "integer"},
+				"code":       jsonObject{"type": "string"},
+				"message":    jsonObject{"type": "string"},
+				"request_id": jsonObject{"type": "string"},
+			},
+		}
+	}
+
+	b, err := json.Marshal(spec)
+	return string(b), err
+}
+
+// envelopeOperation rewrites an operation's responses to the v2 envelope,
+// and the limit and offset params of collections to page and per_page.
+func envelopeOperation(op jsonObject, get bool) {
+	paged := false
+
+	responses, _ := op["responses"].(jsonObject)
+	for code, response := range responses {
+		response := response.(jsonObject)
+
+		status, _ := strconv.Atoi(code)
+		if status >= http.StatusBadRequest {
+			response["schema"] = jsonObject{"$ref": "#/definitions/rest.Envelope"}
+			continue
+		}
+
+		schema, ok := response["schema"].(jsonObject)
+		if !ok {
+			continue
+		}
+
+		if get && schema["type"] == "array" {
+			paged = true
+		}
+
+		response["schema"] = jsonObject{
+			"allOf": []interface{}{
+				jsonObject{"$ref": "#/definitions/rest.Envelope"},
+				jsonObject{"type": "object", "properties": jsonObject{"data": schema}},
+			},
+		}
+	}
+
+	params, _ := op["parameters"].([]interface{})
+	kept := make([]interface{}, 0, len(params))
+	for _, param := range params {
+		p := param.(jsonObject)
+		if p["in"] == "query" && (p["name"] == "limit" || p["name"] == "offset") {
+			paged = true
+			continue
+		}
+
+		kept = append(kept, param)
+	}
+
+	if paged {
+		kept = append(kept,
+			jsonObject{"type": "integer", "name": "page", "in": "query", "description": "Page number, from 1"},
+			jsonObject{"type": "integer", "name": "per_page", "in": "query", "description": "Page size, up to 100"},
+		)
+	}
+
+	if len(kept) > 0 {
+		op["parameters"] = kept
+	}
+}
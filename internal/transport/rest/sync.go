@@ -0,0 +1,99 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/pkg/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Get Changes
+// @Description Get the lists and items created, updated or deleted since the sync token, or all of them without one. Deleted records come as tombstones. Pass the returned token as since next time, and right away while has_more is set. A 410 means the token is too old and the client has to sync from scratch
+// @Security ApiKeyAuth
+// @Tags sync
+// @Produce json
+// @Param since query string false "Token returned by the previous sync"
+// @Param limit query int false "Maximum number of changes"
+// @Success 200 {object} domain.SyncChanges
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 410 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/sync [get]
+func (h *Handler) getChanges(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	var limit int
+	if value := c.Query("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil {
+			httputil.NewError(c, http.StatusBadRequest, errors.New("invalid limit param"))
+			return
+		}
+	}
+
+	changes, err := h.SyncService.GetChanges(c.Request.Context(), userId, c.Query("since"), limit)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidSyncToken) {
+			httputil.NewError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if errors.Is(err, domain.ErrSyncTokenExpired) {
+			httputil.NewError(c, http.StatusGone, err)
+			return
+		}
+
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, changes)
+}
+
+// @Summary Apply Changes
+// @Description Apply changes made offline, in order and each on its own. Updates and deletes with a base version conflict when the record changed on the server since, the result then holds the server's version
+// @Security ApiKeyAuth
+// @Tags sync
+// @Accept json
+// @Produce json
+// @Param input body domain.SyncInput true "Client changes"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response"
+// @Success 200 {array} domain.SyncResult
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 409 {object} httputil.HTTPError
+// @Failure 422 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/sync [post]
+func (h *Handler) applyChanges(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	var input domain.SyncInput
+	if err := c.BindJSON(&input); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	results, err := h.SyncService.ApplyChanges(c.Request.Context(), userId, input)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidSync) {
+			httputil.NewError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
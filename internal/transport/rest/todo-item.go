@@ -18,8 +18,11 @@ import (
 // @Produce json
 // @Param id path int true "List ID"
 // @Param item body domain.TodoItem true "Todo item info"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response"
 // @Success 200 {integer} integer 1
 // @Failure 400 {object} httputil.HTTPError
+// @Failure 409 {object} httputil.HTTPError
+// @Failure 422 {object} httputil.HTTPError
 // @Failure 500 {object} httputil.HTTPError
 // @Router /api/lists/{id}/items [post]
 func (h *Handler) createItem(c *gin.Context) {
@@ -42,7 +45,7 @@ func (h *Handler) createItem(c *gin.Context) {
 		return
 	}
 
-	id, err := h.TodoItemService.CreateItem(userId, listId, item)
+	id, err := h.TodoItemService.CreateItem(c.Request.Context(), userId, listId, item)
 	if err != nil {
 		httputil.NewError(c, http.StatusInternalServerError, err)
 		return
@@ -74,7 +77,7 @@ func (h *Handler) getAllItems(c *gin.Context) {
 		return
 	}
 
-	items, err := h.TodoItemService.GetAllItems(userId, listId)
+	items, err := h.TodoItemService.GetAllItems(c.Request.Context(), userId, listId)
 	if err != nil {
 		httputil.NewError(c, http.StatusInternalServerError, err)
 		return
@@ -89,7 +92,10 @@ func (h *Handler) getAllItems(c *gin.Context) {
 // @Tags items
 // @Produce json
 // @Param id path int true "Item ID"
+// @Param If-None-Match header string false "ETag of a cached copy"
 // @Success 200 {object} domain.TodoItem
+// @Success 304 "Not Modified"
+// @Header 200 {string} ETag "Item version"
 // @Failure 400 {object} httputil.HTTPError
 // @Failure 500 {object} httputil.HTTPError
 // @Router /api/items/{id} [get]
@@ -106,25 +112,31 @@ func (h *Handler) getItemById(c *gin.Context) {
 		return
 	}
 
-	item, err := h.TodoItemService.GetItemById(userId, itemId)
+	item, err := h.TodoItemService.GetItemById(c.Request.Context(), userId, itemId)
 	if err != nil {
 		httputil.NewError(c, http.StatusInternalServerError, err)
 		return
 	}
 
+	if notModified(c, item.Version) {
+		return
+	}
+
 	c.JSON(http.StatusOK, item)
 }
 
-// @Summary Update Item
-// @Description Update an existing todo item
+// @Summary Replace Item
+// @Description Replace a todo item, fields missing from the body are reset
 // @Security ApiKeyAuth
 // @Tags items
 // @Accept json
 // @Produce json
 // @Param id path int true "Item ID"
-// @Param item body domain.UpdateItemInput true "Updated item info"
+// @Param item body domain.ReplaceItemInput true "Updated item info"
+// @Param If-Match header string false "ETag the item must still have"
 // @Success 200 {string} string "ok"
 // @Failure 400 {object} httputil.HTTPError
+// @Failure 412 {object} httputil.HTTPError
 // @Failure 500 {object} httputil.HTTPError
 // @Router /api/items/{id} [put]
 func (h *Handler) updateItem(c *gin.Context) {
@@ -140,17 +152,72 @@ func (h *Handler) updateItem(c *gin.Context) {
 		return
 	}
 
-	var item domain.UpdateItemInput
+	var item domain.ReplaceItemInput
 	if err := c.BindJSON(&item); err != nil {
 		httputil.NewError(c, http.StatusBadRequest, err)
 		return
 	}
 
-	if err := h.TodoItemService.UpdateItem(userId, itemId, item); err != nil {
+	item.Version, err = getIfMatchVersion(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.TodoItemService.ReplaceItem(c.Request.Context(), userId, itemId, item); err != nil {
+		if errors.Is(err, domain.ErrVersionMismatch) {
+			httputil.NewError(c, http.StatusPreconditionFailed, err)
+			return
+		}
+
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// @Summary Patch Item
+// @Description Partially update a todo item with a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902), setting description to null clears it
+// @Security ApiKeyAuth
+// @Tags items
+// @Accept application/merge-patch+json,application/json-patch+json
+// @Produce json
+// @Param id path int true "Item ID"
+// @Param patch body object true "Merge patch or array of patch operations"
+// @Param If-Match header string false "ETag the item must still have"
+// @Success 200 {string} string "ok"
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 409 {object} httputil.HTTPError
+// @Failure 412 {object} httputil.HTTPError
+// @Failure 415 {object} httputil.HTTPError
+// @Failure 422 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/items/{id} [patch]
+func (h *Handler) patchItem(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
 		httputil.NewError(c, http.StatusInternalServerError, err)
 		return
 	}
 
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("invalid id param"))
+		return
+	}
+
+	patch, err := getPatch(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.TodoItemService.PatchItem(c.Request.Context(), userId, itemId, patch); err != nil {
+		httputil.NewError(c, patchErrorStatus(err), err)
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
 
@@ -177,11 +244,171 @@ func (h *Handler) deleteItem(c *gin.Context) {
 		return
 	}
 
-	err = h.TodoItemService.DeleteItem(userId, itemId)
+	err = h.TodoItemService.DeleteItem(c.Request.Context(), userId, itemId)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// @Summary Move Item
+// @Description Move a todo item to another list
+// @Security ApiKeyAuth
+// @Tags items
+// @Accept json
+// @Produce json
+// @Param id path int true "Item ID"
+// @Param input body domain.MoveItemInput true "Destination list"
+// @Success 200 {string} string "ok"
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/items/{id}/move [post]
+func (h *Handler) moveItem(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("invalid id param"))
+		return
+	}
+
+	var input domain.MoveItemInput
+	if err := c.BindJSON(&input); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.TodoItemService.MoveItem(c.Request.Context(), userId, itemId, input); err != nil {
 		httputil.NewError(c, http.StatusInternalServerError, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
+
+// @Summary Copy Item
+// @Description Copy a todo item into another list
+// @Security ApiKeyAuth
+// @Tags items
+// @Accept json
+// @Produce json
+// @Param id path int true "Item ID"
+// @Param input body domain.MoveItemInput true "Destination list"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response"
+// @Success 200 {integer} integer 1
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 409 {object} httputil.HTTPError
+// @Failure 422 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/items/{id}/copy [post]
+func (h *Handler) copyItem(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	itemId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("invalid id param"))
+		return
+	}
+
+	var input domain.MoveItemInput
+	if err := c.BindJSON(&input); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	id, err := h.TodoItemService.CopyItem(c.Request.Context(), userId, itemId, input)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id})
+}
+
+// @Summary Move Items
+// @Description Move several todo items to another list in a single transaction
+// @Security ApiKeyAuth
+// @Tags items
+// @Accept json
+// @Produce json
+// @Param input body domain.MoveItemsInput true "Items and destination list"
+// @Success 200 {string} string "ok"
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/items/move [post]
+func (h *Handler) moveItems(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	var input domain.MoveItemsInput
+	if err := c.BindJSON(&input); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.TodoItemService.MoveItems(c.Request.Context(), userId, input); err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// @Summary Bulk Item Operations
+// @Description Update, complete, delete or move up to 100 todo items in one request. In atomic mode a failing operation rolls back the whole batch and the response is 422, in partial mode every operation reports its own result
+// @Security ApiKeyAuth
+// @Tags items
+// @Accept json
+// @Produce json
+// @Param input body domain.BulkInput true "Mode and operations"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response"
+// @Success 200 {object} domain.BulkResponse
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 409 {object} httputil.HTTPError
+// @Failure 422 {object} domain.BulkResponse
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/items/bulk [post]
+func (h *Handler) bulkItems(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	var input domain.BulkInput
+	if err := c.BindJSON(&input); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	response, err := h.TodoItemService.ApplyBulk(c.Request.Context(), userId, input)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidBulk) {
+			httputil.NewError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		if errors.Is(err, domain.ErrBulkFailed) {
+			c.JSON(http.StatusUnprocessableEntity, response)
+			return
+		}
+
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
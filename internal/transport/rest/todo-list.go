@@ -17,8 +17,11 @@ import (
 // @Accept json
 // @Produce json
 // @Param list body domain.TodoList true "Todo list info"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response"
 // @Success 200 {integer} integer 1
 // @Failure 400 {object} httputil.HTTPError
+// @Failure 409 {object} httputil.HTTPError
+// @Failure 422 {object} httputil.HTTPError
 // @Failure 500 {object} httputil.HTTPError
 // @Router /api/lists [post]
 func (h *Handler) createList(c *gin.Context) {
@@ -35,7 +38,7 @@ func (h *Handler) createList(c *gin.Context) {
 		return
 	}
 
-	id, err := h.TodoListService.CreateList(userId, list)
+	id, err := h.TodoListService.CreateList(c.Request.Context(), userId, list)
 	if err != nil {
 		httputil.NewError(c, http.StatusInternalServerError, err)
 		return
@@ -45,11 +48,13 @@ func (h *Handler) createList(c *gin.Context) {
 }
 
 // @Summary Get All Lists
-// @Description Get all todo lists for a user
+// @Description Get all todo lists for a user, archived lists are hidden unless requested
 // @Security ApiKeyAuth
 // @Tags lists
 // @Produce json
+// @Param archived query bool false "Include archived lists"
 // @Success 200 {array} domain.TodoList
+// @Failure 400 {object} httputil.HTTPError
 // @Failure 500 {object} httputil.HTTPError
 // @Router /api/lists [get]
 func (h *Handler) getAllLists(c *gin.Context) {
@@ -59,7 +64,16 @@ func (h *Handler) getAllLists(c *gin.Context) {
 		return
 	}
 
-	lists, err := h.TodoListService.GetAllLists(userId)
+	withArchived := false
+	if archived := c.Query("archived"); archived != "" {
+		withArchived, err = strconv.ParseBool(archived)
+		if err != nil {
+			httputil.NewError(c, http.StatusBadRequest, errors.New("invalid archived param"))
+			return
+		}
+	}
+
+	lists, err := h.TodoListService.GetAllLists(c.Request.Context(), userId, withArchived)
 	if err != nil {
 		httputil.NewError(c, http.StatusInternalServerError, err)
 		return
@@ -74,7 +88,10 @@ func (h *Handler) getAllLists(c *gin.Context) {
 // @Tags lists
 // @Produce json
 // @Param id path int true "List ID"
+// @Param If-None-Match header string false "ETag of a cached copy"
 // @Success 200 {object} domain.TodoList
+// @Success 304 "Not Modified"
+// @Header 200 {string} ETag "List version"
 // @Failure 400 {object} httputil.HTTPError
 // @Failure 500 {object} httputil.HTTPError
 // @Router /api/lists/{id} [get]
@@ -91,25 +108,31 @@ func (h *Handler) getListById(c *gin.Context) {
 		return
 	}
 
-	list, err := h.TodoListService.GetListById(userId, listId)
+	list, err := h.TodoListService.GetListById(c.Request.Context(), userId, listId)
 	if err != nil {
 		httputil.NewError(c, http.StatusInternalServerError, err)
 		return
 	}
 
+	if notModified(c, list.Version) {
+		return
+	}
+
 	c.JSON(http.StatusOK, list)
 }
 
-// @Summary Update List
-// @Description Update an existing todo list
+// @Summary Replace List
+// @Description Replace a todo list, fields missing from the body are reset
 // @Security ApiKeyAuth
 // @Tags lists
 // @Accept json
 // @Produce json
 // @Param id path int true "List ID"
-// @Param list body domain.UpdateListInput true "Updated list info"
+// @Param list body domain.ReplaceListInput true "Updated list info"
+// @Param If-Match header string false "ETag the list must still have"
 // @Success 200 {string} string "ok"
 // @Failure 400 {object} httputil.HTTPError
+// @Failure 412 {object} httputil.HTTPError
 // @Failure 500 {object} httputil.HTTPError
 // @Router /api/lists/{id} [put]
 func (h *Handler) updateList(c *gin.Context) {
@@ -125,13 +148,24 @@ func (h *Handler) updateList(c *gin.Context) {
 		return
 	}
 
-	var list domain.UpdateListInput
+	var list domain.ReplaceListInput
 	if err := c.BindJSON(&list); err != nil {
 		httputil.NewError(c, http.StatusBadRequest, err)
 		return
 	}
 
-	if err := h.TodoListService.UpdateList(userId, listId, list); err != nil {
+	list.Version, err = getIfMatchVersion(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.TodoListService.ReplaceList(c.Request.Context(), userId, listId, list); err != nil {
+		if errors.Is(err, domain.ErrVersionMismatch) {
+			httputil.NewError(c, http.StatusPreconditionFailed, err)
+			return
+		}
+
 		httputil.NewError(c, http.StatusInternalServerError, err)
 		return
 	}
@@ -139,14 +173,59 @@ func (h *Handler) updateList(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
 
+// @Summary Patch List
+// @Description Partially update a todo list with a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902), setting description to null clears it
+// @Security ApiKeyAuth
+// @Tags lists
+// @Accept application/merge-patch+json,application/json-patch+json
+// @Produce json
+// @Param id path int true "List ID"
+// @Param patch body object true "Merge patch or array of patch operations"
+// @Param If-Match header string false "ETag the list must still have"
+// @Success 200 {string} string "ok"
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 409 {object} httputil.HTTPError
+// @Failure 412 {object} httputil.HTTPError
+// @Failure 415 {object} httputil.HTTPError
+// @Failure 422 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/lists/{id} [patch]
+func (h *Handler) patchList(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	listId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("invalid id param"))
+		return
+	}
+
+	patch, err := getPatch(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.TodoListService.PatchList(c.Request.Context(), userId, listId, patch); err != nil {
+		httputil.NewError(c, patchErrorStatus(err), err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
 // @Summary Delete List
-// @Description Delete a specific todo list by its ID
+// @Description Delete a specific todo list by its ID, only the list owner can delete a shared list
 // @Security ApiKeyAuth
 // @Tags lists
 // @Produce json
 // @Param id path int true "List ID"
 // @Success 200 {string} string "ok"
 // @Failure 400 {object} httputil.HTTPError
+// @Failure 403 {object} httputil.HTTPError
 // @Failure 500 {object} httputil.HTTPError
 // @Router /api/lists/{id} [delete]
 func (h *Handler) deleteList(c *gin.Context) {
@@ -162,7 +241,180 @@ func (h *Handler) deleteList(c *gin.Context) {
 		return
 	}
 
-	if err := h.TodoListService.DeleteList(userId, listId); err != nil {
+	if err := h.TodoListService.DeleteList(c.Request.Context(), userId, listId); err != nil {
+		if errors.Is(err, domain.ErrNotListOwner) {
+			httputil.NewError(c, http.StatusForbidden, err)
+			return
+		}
+
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// @Summary Duplicate List
+// @Description Deep copy a todo list together with its items
+// @Security ApiKeyAuth
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Param id path int true "List ID"
+// @Param input body domain.DuplicateListInput false "Duplicate options"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response"
+// @Success 200 {integer} integer 1
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 409 {object} httputil.HTTPError
+// @Failure 422 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/lists/{id}/duplicate [post]
+func (h *Handler) duplicateList(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	listId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("invalid id param"))
+		return
+	}
+
+	var input domain.DuplicateListInput
+	if c.Request.ContentLength != 0 {
+		if err := c.BindJSON(&input); err != nil {
+			httputil.NewError(c, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	id, err := h.TodoListService.DuplicateList(c.Request.Context(), userId, listId, input)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id})
+}
+
+// @Summary Get Templates
+// @Description Get all list templates available to a user
+// @Security ApiKeyAuth
+// @Tags lists
+// @Produce json
+// @Success 200 {array} domain.TodoList
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/lists/templates [get]
+func (h *Handler) getTemplates(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	lists, err := h.TodoListService.GetTemplates(c.Request.Context(), userId)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, lists)
+}
+
+// @Summary Create List From Template
+// @Description Create a new todo list from a template, substituting the placeholder values in titles
+// @Security ApiKeyAuth
+// @Tags lists
+// @Accept json
+// @Produce json
+// @Param id path int true "Template ID"
+// @Param input body domain.FromTemplateInput false "Placeholder values"
+// @Param Idempotency-Key header string false "Retries with the same key replay the first response"
+// @Success 200 {integer} integer 1
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 409 {object} httputil.HTTPError
+// @Failure 422 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/lists/from-template/{id} [post]
+func (h *Handler) createFromTemplate(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	templateId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("invalid id param"))
+		return
+	}
+
+	var input domain.FromTemplateInput
+	if c.Request.ContentLength != 0 {
+		if err := c.BindJSON(&input); err != nil {
+			httputil.NewError(c, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	id, err := h.TodoListService.CreateFromTemplate(c.Request.Context(), userId, templateId, input)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotTemplate) {
+			httputil.NewError(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id})
+}
+
+// @Summary Archive List
+// @Description Archive a finished todo list, hiding it from the lists overview
+// @Security ApiKeyAuth
+// @Tags lists
+// @Produce json
+// @Param id path int true "List ID"
+// @Success 200 {string} string "ok"
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/lists/{id}/archive [post]
+func (h *Handler) archiveList(c *gin.Context) {
+	h.setListArchived(c, true)
+}
+
+// @Summary Unarchive List
+// @Description Return an archived todo list to the lists overview
+// @Security ApiKeyAuth
+// @Tags lists
+// @Produce json
+// @Param id path int true "List ID"
+// @Success 200 {string} string "ok"
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/lists/{id}/unarchive [post]
+func (h *Handler) unarchiveList(c *gin.Context) {
+	h.setListArchived(c, false)
+}
+
+func (h *Handler) setListArchived(c *gin.Context, archived bool) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	listId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("invalid id param"))
+		return
+	}
+
+	if err := h.TodoListService.SetArchived(c.Request.Context(), userId, listId, archived); err != nil {
 		httputil.NewError(c, http.StatusInternalServerError, err)
 		return
 	}
@@ -0,0 +1,73 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/pkg/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Get Trash
+// @Description Get deleted todo lists and items that can still be restored
+// @Security ApiKeyAuth
+// @Tags trash
+// @Produce json
+// @Success 200 {array} domain.TrashEntry
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/trash [get]
+func (h *Handler) getTrash(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	entries, err := h.TrashService.GetTrash(c.Request.Context(), userId)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// @Summary Restore From Trash
+// @Description Restore a deleted todo list or item
+// @Security ApiKeyAuth
+// @Tags trash
+// @Produce json
+// @Param type path string true "Entry type" Enums(list, item)
+// @Param id path int true "List or item ID"
+// @Success 200 {string} string "ok"
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/trash/{type}/{id}/restore [post]
+func (h *Handler) restoreFromTrash(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	entityType := c.Param("type")
+	if err := domain.ValidateTrashType(entityType); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("invalid id param"))
+		return
+	}
+
+	if err := h.TrashService.Restore(c.Request.Context(), userId, entityType, id); err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
@@ -0,0 +1,317 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/pkg/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	apiVersionCtx = "apiVersion"
+	pageCtx       = "page"
+	pagedCtx      = "paged"
+
+	apiVersionHeader  = "API-Version"
+	deprecationHeader = "Deprecation"
+	sunsetHeader      = "Sunset"
+	linkHeader        = "Link"
+
+	// versionMediaType requests a version in the Accept header, e.g.
+	// "application/vnd.crud-app.v2+json". "application/json; version=2"
+	// works as well.
+	versionMediaType = "application/vnd.crud-app.v%d+json"
+
+	apiV1 = 1
+	apiV2 = 2
+)
+
+// Versioning holds the v1 deprecation dates announced to clients.
+type Versioning struct {
+	V1DeprecatedAt time.Time
+	V1SunsetAt     time.Time
+}
+
+var errUnsupportedVersion = errors.New("unsupported api version, use 1 or 2")
+
+// negotiateVersion picks the API version of an unversioned /api request
+// from its Accept header, defaulting to v1.
+func (h *Handler) negotiateVersion(c *gin.Context) {
+	c.Header("Vary", "Accept")
+
+	version, err := getAcceptVersion(c.GetHeader("Accept"))
+	if err != nil {
+		httputil.NewError(c, http.StatusNotAcceptable, err)
+		c.Abort()
+		return
+	}
+
+	h.useVersion(c, version)
+}
+
+// apiVersion serves the /api/v<version> route group.
+func (h *Handler) apiVersion(version int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h.useVersion(c, version)
+	}
+}
+
+func (h *Handler) useVersion(c *gin.Context, version int) {
+	c.Set(apiVersionCtx, version)
+	c.Header(apiVersionHeader, strconv.Itoa(version))
+
+	if version == apiV1 {
+		h.setDeprecationHeaders(c)
+		return
+	}
+
+	writer := &envelopeWriter{ResponseWriter: c.Writer}
+	c.Writer = writer
+
+	if c.Request.Method == http.MethodGet {
+		if _, err := getV2Pagination(c); err != nil {
+			httputil.NewError(c, http.StatusBadRequest, err)
+			c.Abort()
+		}
+	}
+
+	c.Next()
+
+	writer.finish(c)
+}
+
+// setDeprecationHeaders announces the v1 deprecation (RFC 9745) and sunset
+// (RFC 8594) and links the request's v2 successor.
+func (h *Handler) setDeprecationHeaders(c *gin.Context) {
+	if !h.Versioning.V1DeprecatedAt.IsZero() {
+		c.Header(deprecationHeader, fmt.Sprintf("@%d", h.Versioning.V1DeprecatedAt.Unix()))
+	}
+
+	if !h.Versioning.V1SunsetAt.IsZero() {
+		c.Header(sunsetHeader, h.Versioning.V1SunsetAt.UTC().Format(http.TimeFormat))
+	}
+
+	path := strings.TrimPrefix(strings.TrimPrefix(c.Request.URL.Path, "/api"), "/v1")
+	c.Header(linkHeader, fmt.Sprintf(`</api/v2%s>; rel="successor-version"`, path))
+}
+
+func getApiVersion(c *gin.Context) int {
+	if version, ok := c.Get(apiVersionCtx); ok {
+		return version.(int)
+	}
+
+	return apiV1
+}
+
+// getAcceptVersion returns the first version requested in the Accept
+// header, or v1 when none is.
+func getAcceptVersion(header string) (int, error) {
+	for _, accept := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err != nil {
+			continue
+		}
+
+		var version int
+		if v, ok := params["version"]; ok {
+			if version, err = strconv.Atoi(v); err != nil {
+				return 0, errUnsupportedVersion
+			}
+		} else if _, err := fmt.Sscanf(mediaType, versionMediaType, &version); err != nil {
+			continue
+		}
+
+		if version != apiV1 && version != apiV2 {
+			return 0, errUnsupportedVersion
+		}
+
+		return version, nil
+	}
+
+	return apiV1, nil
+}
+
+// getV2Pagination reads the v2 page and per_page query params and keeps
+// them for the response meta.
+func getV2Pagination(c *gin.Context) (domain.Pagination, error) {
+	page := PageMeta{Page: 1, PerPage: domain.DefaultPageLimit}
+	var err error
+
+	if p := c.Query("page"); p != "" {
+		page.Page, err = strconv.Atoi(p)
+		if err != nil || page.Page < 1 {
+			return domain.Pagination{}, errors.New("invalid page param")
+		}
+	}
+
+	if perPage := c.Query("per_page"); perPage != "" {
+		page.PerPage, err = strconv.Atoi(perPage)
+		if err != nil || page.PerPage < 1 {
+			return domain.Pagination{}, errors.New("invalid per_page param")
+		}
+	}
+
+	pagination := domain.Pagination{Limit: page.PerPage, Offset: (page.Page - 1) * page.PerPage}
+	pagination.Normalize()
+	page.PerPage = pagination.Limit
+
+	c.Set(pageCtx, page)
+
+	return pagination, nil
+}
+
+// Envelope is the v2 response body.
+type Envelope struct {
+	Data  json.RawMessage `json:"data,omitempty"`
+	Meta  *PageMeta       `json:"meta,omitempty"`
+	Error *ErrorBody      `json:"error,omitempty"`
+}
+
+type PageMeta struct {
+	Page    int `json:"page"`
+	PerPage int `json:"per_page"`
+	// Total is set when the whole collection was loaded.
+	Total *int `json:"total,omitempty"`
+}
+
+// ErrorBody is the v2 error, code is the snake-cased status text.
+type ErrorBody struct {
+	Status    int    `json:"status"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestId string `json:"request_id,omitempty"`
+}
+
+func newErrorBody(c *gin.Context, status int, message string) *ErrorBody {
+	return &ErrorBody{
+		Status:    status,
+		Code:      strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_"),
+		Message:   message,
+		RequestId: c.GetString(httputil.RequestIdCtx),
+	}
+}
+
+// envelopeWriter buffers JSON responses to wrap them in an Envelope, other
+// responses (streams, downloads, upgrades) are written through.
+type envelopeWriter struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	buffered bool
+	decided  bool
+}
+
+func (w *envelopeWriter) decide() {
+	if w.decided {
+		return
+	}
+	w.decided = true
+
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	w.buffered = mediaType == gin.MIMEJSON
+}
+
+func (w *envelopeWriter) Write(b []byte) (int, error) {
+	w.decide()
+	if w.buffered {
+		return w.body.Write(b)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *envelopeWriter) WriteString(s string) (int, error) {
+	w.decide()
+	if w.buffered {
+		return w.body.WriteString(s)
+	}
+
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *envelopeWriter) Flush() {
+	w.decide()
+	if !w.buffered {
+		w.ResponseWriter.Flush()
+	}
+}
+
+func (w *envelopeWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// finish writes the buffered response in its envelope.
+func (w *envelopeWriter) finish(c *gin.Context) {
+	if !w.buffered {
+		return
+	}
+
+	var envelope Envelope
+
+	// Only the first value counts, a handler may have written an error
+	// after its middleware already did.
+	var data json.RawMessage
+	if err := json.NewDecoder(&w.body).Decode(&data); err != nil {
+		data = nil
+	}
+
+	status := w.Status()
+	if status >= http.StatusBadRequest {
+		envelope.Error = newErrorBody(c, status, http.StatusText(status))
+
+		var httpErr httputil.HTTPError
+		if json.Unmarshal(data, &httpErr) == nil && httpErr.Message != "" {
+			envelope.Error.Message = httpErr.Message
+		} else {
+			envelope.Data = data
+		}
+	} else {
+		envelope.Data, envelope.Meta = paginate(c, data)
+	}
+
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		body = []byte(`{"error":{"status":500,"code":"internal_server_error","message":"Internal Server Error"}}`)
+	}
+
+	w.ResponseWriter.Write(body)
+}
+
+// paginate returns the page meta of a GET collection. Collections the
+// handler didn't page are sliced here.
+func paginate(c *gin.Context, data json.RawMessage) (json.RawMessage, *PageMeta) {
+	if c.Request.Method != http.MethodGet || !bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		return data, nil
+	}
+
+	meta := c.MustGet(pageCtx).(PageMeta)
+	if c.GetBool(pagedCtx) {
+		return data, &meta
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return data, nil
+	}
+
+	total := len(items)
+	meta.Total = &total
+
+	start := min((meta.Page-1)*meta.PerPage, total)
+	end := min(start+meta.PerPage, total)
+
+	paged, err := json.Marshal(items[start:end])
+	if err != nil {
+		return data, nil
+	}
+
+	return paged, &meta
+}
@@ -0,0 +1,163 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetAcceptVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    int
+		wantErr error
+	}{
+		{name: "absent", header: "", want: apiV1},
+		{name: "any", header: "*/*", want: apiV1},
+		{name: "plain json", header: "application/json", want: apiV1},
+		{name: "vendor v1", header: "application/vnd.crud-app.v1+json", want: apiV1},
+		{name: "vendor v2", header: "application/vnd.crud-app.v2+json", want: apiV2},
+		{name: "version param", header: "application/json; version=2", want: apiV2},
+		{name: "quoted version param", header: `application/json; version="2"`, want: apiV2},
+		{name: "first version wins", header: "text/html, application/vnd.crud-app.v2+json, application/json; version=1", want: apiV2},
+		{name: "malformed entries are skipped", header: "a/b;;, application/vnd.crud-app.v2+json", want: apiV2},
+		{name: "other vendor type", header: "application/vnd.other.v2+json", want: apiV1},
+		{name: "unknown vendor version", header: "application/vnd.crud-app.v3+json", wantErr: errUnsupportedVersion},
+		{name: "unknown version param", header: "application/json; version=3", wantErr: errUnsupportedVersion},
+		{name: "invalid version param", header: "application/json; version=two", wantErr: errUnsupportedVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getAcceptVersion(tt.header)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("got v%d, want v%d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		method    string
+		query     string
+		paged     bool
+		data      string
+		want      string
+		wantMeta  bool
+		wantPage  PageMeta
+		wantTotal int
+	}{
+		{
+			name:      "first page",
+			query:     "per_page=2",
+			data:      `[1,2,3,4,5]`,
+			want:      `[1,2]`,
+			wantMeta:  true,
+			wantPage:  PageMeta{Page: 1, PerPage: 2},
+			wantTotal: 5,
+		},
+		{
+			name:      "last partial page",
+			query:     "page=3&per_page=2",
+			data:      `[1,2,3,4,5]`,
+			want:      `[5]`,
+			wantMeta:  true,
+			wantPage:  PageMeta{Page: 3, PerPage: 2},
+			wantTotal: 5,
+		},
+		{
+			name:      "past the end",
+			query:     "page=9&per_page=2",
+			data:      `[1,2,3]`,
+			want:      `[]`,
+			wantMeta:  true,
+			wantPage:  PageMeta{Page: 9, PerPage: 2},
+			wantTotal: 3,
+		},
+		{
+			name:      "default page size",
+			data:      `[1,2,3]`,
+			want:      `[1,2,3]`,
+			wantMeta:  true,
+			wantPage:  PageMeta{Page: 1, PerPage: 20},
+			wantTotal: 3,
+		},
+		{
+			name:      "page size is capped",
+			query:     "per_page=1000",
+			data:      `[]`,
+			want:      `[]`,
+			wantMeta:  true,
+			wantPage:  PageMeta{Page: 1, PerPage: 100},
+			wantTotal: 0,
+		},
+		{
+			name:     "paged by the handler",
+			query:    "page=2&per_page=2",
+			paged:    true,
+			data:     `[3,4]`,
+			want:     `[3,4]`,
+			wantMeta: true,
+			wantPage: PageMeta{Page: 2, PerPage: 2},
+		},
+		{name: "object", data: `{"id":1}`, want: `{"id":1}`},
+		{name: "not a get", method: http.MethodPost, data: `[1,2,3]`, want: `[1,2,3]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(method, "/?"+tt.query, nil)
+			if _, err := getV2Pagination(c); err != nil {
+				t.Fatal(err)
+			}
+			if tt.paged {
+				c.Set(pagedCtx, true)
+			}
+
+			data, meta := paginate(c, json.RawMessage(tt.data))
+			if string(data) != tt.want {
+				t.Errorf("got %s, want %s", data, tt.want)
+			}
+
+			if (meta != nil) != tt.wantMeta {
+				t.Fatalf("got meta %+v, want meta: %v", meta, tt.wantMeta)
+			}
+
+			if meta == nil {
+				return
+			}
+
+			if meta.Page != tt.wantPage.Page || meta.PerPage != tt.wantPage.PerPage {
+				t.Errorf("got page %d of %d, want page %d of %d", meta.Page, meta.PerPage,
+					tt.wantPage.Page, tt.wantPage.PerPage)
+			}
+
+			if tt.paged != (meta.Total == nil) {
+				t.Fatalf("got total %v, want it set only for sliced collections", meta.Total)
+			}
+
+			if meta.Total != nil && *meta.Total != tt.wantTotal {
+				t.Errorf("got total %d, want %d", *meta.Total, tt.wantTotal)
+			}
+		})
+	}
+}
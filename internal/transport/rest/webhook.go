@@ -0,0 +1,252 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/SavelyDev/crud-app/internal/domain"
+	"github.com/SavelyDev/crud-app/pkg/httputil"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Create Webhook
+// @Description Register a webhook for list and item changes, payloads are signed with the returned secret
+// @Security ApiKeyAuth
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param webhook body domain.WebhookInput true "Webhook url, event types and list filter"
+// @Success 200 {object} map[string]interface{} "id and secret"
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/webhooks [post]
+func (h *Handler) createWebhook(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	var input domain.WebhookInput
+	if err := c.BindJSON(&input); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	id, secret, err := h.WebhookService.CreateWebhook(c.Request.Context(), userId, input)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidWebhook) {
+			httputil.NewError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id, "secret": secret})
+}
+
+// @Summary Get Webhooks
+// @Description Get the user's webhooks
+// @Security ApiKeyAuth
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} domain.Webhook
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/webhooks [get]
+func (h *Handler) getWebhooks(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	webhooks, err := h.WebhookService.GetWebhooks(c.Request.Context(), userId)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// @Summary Get Webhook By Id
+// @Description Get one of the user's webhooks
+// @Security ApiKeyAuth
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} domain.Webhook
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/webhooks/{id} [get]
+func (h *Handler) getWebhookById(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	webhookId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("invalid id param"))
+		return
+	}
+
+	webhook, err := h.WebhookService.GetWebhookById(c.Request.Context(), userId, webhookId)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// @Summary Update Webhook
+// @Description Replace a webhook's settings, setting active re-enables a webhook disabled after repeated failures
+// @Security ApiKeyAuth
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param webhook body domain.WebhookInput true "Webhook url, event types and list filter"
+// @Success 200 {string} string "ok"
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/webhooks/{id} [put]
+func (h *Handler) updateWebhook(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	webhookId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("invalid id param"))
+		return
+	}
+
+	var input domain.WebhookInput
+	if err := c.BindJSON(&input); err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.WebhookService.UpdateWebhook(c.Request.Context(), userId, webhookId, input); err != nil {
+		if errors.Is(err, domain.ErrInvalidWebhook) {
+			httputil.NewError(c, http.StatusBadRequest, err)
+			return
+		}
+
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// @Summary Delete Webhook
+// @Description Delete a webhook and its delivery log
+// @Security ApiKeyAuth
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {string} string "ok"
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/webhooks/{id} [delete]
+func (h *Handler) deleteWebhook(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	webhookId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("invalid id param"))
+		return
+	}
+
+	if err := h.WebhookService.DeleteWebhook(c.Request.Context(), userId, webhookId); err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// @Summary Test Webhook
+// @Description Queue a ping event for the webhook, it is sent even if the webhook is disabled
+// @Security ApiKeyAuth
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {integer} integer 1
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/webhooks/{id}/test [post]
+func (h *Handler) testWebhook(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	webhookId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("invalid id param"))
+		return
+	}
+
+	id, err := h.WebhookService.SendTestEvent(c.Request.Context(), userId, webhookId)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id})
+}
+
+// @Summary Get Webhook Deliveries
+// @Description Get the delivery log of a webhook, newest first
+// @Security ApiKeyAuth
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param limit query int false "Page size"
+// @Param offset query int false "Page offset"
+// @Success 200 {array} domain.WebhookDelivery
+// @Failure 400 {object} httputil.HTTPError
+// @Failure 500 {object} httputil.HTTPError
+// @Router /api/webhooks/{id}/deliveries [get]
+func (h *Handler) getWebhookDeliveries(c *gin.Context) {
+	userId, err := getUserId(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	webhookId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, errors.New("invalid id param"))
+		return
+	}
+
+	page, err := getPagination(c)
+	if err != nil {
+		httputil.NewError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	deliveries, err := h.WebhookService.GetDeliveries(c.Request.Context(), userId, webhookId, page)
+	if err != nil {
+		httputil.NewError(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
@@ -0,0 +1,6 @@
+ALTER TABLE todo_items
+    DROP COLUMN deleted_at;
+
+ALTER TABLE todo_lists
+    DROP COLUMN archived_at,
+    DROP COLUMN deleted_at;
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TABLE todo_lists
+    ADD COLUMN deleted_at  timestamp,
+    ADD COLUMN archived_at timestamp;
+
+ALTER TABLE todo_items
+    ADD COLUMN deleted_at timestamp;
\ No newline at end of file
@@ -0,0 +1,17 @@
+DROP INDEX todo_items_search_idx;
+
+DROP INDEX todo_lists_search_idx;
+
+DROP TRIGGER todo_items_search_vector ON todo_items;
+
+DROP TRIGGER todo_lists_search_vector ON todo_lists;
+
+DROP FUNCTION update_search_vector;
+
+ALTER TABLE todo_items
+    DROP COLUMN search_vector;
+
+ALTER TABLE todo_lists
+    DROP COLUMN search_vector;
+
+DROP TABLE search_settings;
\ No newline at end of file
@@ -0,0 +1,44 @@
+CREATE TABLE search_settings
+(
+    language regconfig not null
+);
+
+INSERT INTO search_settings (language) VALUES ('english');
+
+ALTER TABLE todo_lists
+    ADD COLUMN search_vector tsvector;
+
+ALTER TABLE todo_items
+    ADD COLUMN search_vector tsvector;
+
+CREATE FUNCTION update_search_vector() RETURNS trigger AS
+$$
+DECLARE
+    lang regconfig := (SELECT language FROM search_settings LIMIT 1);
+BEGIN
+    NEW.search_vector :=
+                setweight(to_tsvector(lang, coalesce(NEW.title, '')), 'A') ||
+                setweight(to_tsvector(lang, coalesce(NEW.description, '')), 'B');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER todo_lists_search_vector
+    BEFORE INSERT OR UPDATE
+    ON todo_lists
+    FOR EACH ROW
+EXECUTE FUNCTION update_search_vector();
+
+CREATE TRIGGER todo_items_search_vector
+    BEFORE INSERT OR UPDATE
+    ON todo_items
+    FOR EACH ROW
+EXECUTE FUNCTION update_search_vector();
+
+UPDATE todo_lists SET search_vector = NULL;
+
+UPDATE todo_items SET search_vector = NULL;
+
+CREATE INDEX todo_lists_search_idx ON todo_lists USING GIN (search_vector);
+
+CREATE INDEX todo_items_search_idx ON todo_items USING GIN (search_vector);
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP TABLE notifications;
+
+DROP TABLE item_comments;
\ No newline at end of file
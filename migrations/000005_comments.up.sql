@@ -0,0 +1,25 @@
+CREATE TABLE item_comments
+(
+    id         serial                                           not null unique,
+    item_id    int references todo_items (id) on delete cascade not null,
+    user_id    int references users (id) on delete cascade      not null,
+    body       text                                             not null,
+    created_at timestamp                                        not null default now(),
+    updated_at timestamp                                        not null default now()
+);
+
+CREATE INDEX item_comments_item_idx ON item_comments (item_id, created_at);
+
+CREATE TABLE notifications
+(
+    id         serial                                              not null unique,
+    user_id    int references users (id) on delete cascade         not null,
+    actor_id   int references users (id) on delete cascade         not null,
+    type       varchar(64)                                         not null,
+    item_id    int references todo_items (id) on delete cascade,
+    comment_id int references item_comments (id) on delete cascade,
+    read       boolean                                             not null default false,
+    created_at timestamp                                           not null default now()
+);
+
+CREATE INDEX notifications_user_idx ON notifications (user_id, created_at);
\ No newline at end of file
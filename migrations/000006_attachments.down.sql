@@ -0,0 +1,7 @@
+DROP TRIGGER attachments_orphaned_blob ON attachments;
+
+DROP FUNCTION queue_orphaned_blob;
+
+DROP TABLE orphaned_blobs;
+
+DROP TABLE attachments;
\ No newline at end of file
@@ -0,0 +1,33 @@
+CREATE TABLE attachments
+(
+    id           serial                                           not null unique,
+    item_id      int references todo_items (id) on delete cascade not null,
+    user_id      int references users (id) on delete cascade      not null,
+    file_name    varchar(255)                                     not null,
+    content_type varchar(255)                                     not null,
+    size         bigint                                           not null,
+    storage_key  varchar(255)                                     not null unique,
+    created_at   timestamp                                        not null default now()
+);
+
+CREATE INDEX attachments_item_idx ON attachments (item_id);
+
+CREATE TABLE orphaned_blobs
+(
+    storage_key varchar(255) not null unique,
+    created_at  timestamp    not null default now()
+);
+
+CREATE FUNCTION queue_orphaned_blob() RETURNS trigger AS
+$$
+BEGIN
+    INSERT INTO orphaned_blobs (storage_key) VALUES (OLD.storage_key) ON CONFLICT DO NOTHING;
+    RETURN OLD;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER attachments_orphaned_blob
+    AFTER DELETE
+    ON attachments
+    FOR EACH ROW
+EXECUTE FUNCTION queue_orphaned_blob();
\ No newline at end of file
@@ -0,0 +1,8 @@
+DROP TRIGGER audit_events_append_only ON audit_events;
+
+DROP FUNCTION reject_audit_event_change;
+
+DROP TABLE audit_events;
+
+ALTER TABLE users
+    DROP COLUMN is_admin;
\ No newline at end of file
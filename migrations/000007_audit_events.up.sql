@@ -0,0 +1,32 @@
+ALTER TABLE users
+    ADD COLUMN is_admin boolean not null default false;
+
+CREATE TABLE audit_events
+(
+    id          bigserial   not null unique,
+    entity_type varchar(32) not null,
+    entity_id   int         not null,
+    actor_id    int,
+    action      varchar(32) not null,
+    before      jsonb,
+    after       jsonb,
+    request_id  varchar(64),
+    created_at  timestamp   not null default now()
+);
+
+CREATE INDEX audit_events_entity_idx ON audit_events (entity_type, entity_id, created_at);
+
+CREATE INDEX audit_events_actor_idx ON audit_events (actor_id, created_at);
+
+CREATE FUNCTION reject_audit_event_change() RETURNS trigger AS
+$$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_append_only
+    BEFORE UPDATE OR DELETE
+    ON audit_events
+    FOR EACH ROW
+EXECUTE FUNCTION reject_audit_event_change();
\ No newline at end of file
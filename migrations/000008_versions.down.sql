@@ -0,0 +1,5 @@
+ALTER TABLE todo_items
+    DROP COLUMN version;
+
+ALTER TABLE todo_lists
+    DROP COLUMN version;
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE idempotency_keys
+(
+    user_id      int references users (id) on delete cascade not null,
+    key          varchar(255)                                not null,
+    fingerprint  varchar(64)                                 not null,
+    status_code  int,
+    content_type varchar(255),
+    body         bytea,
+    created_at   timestamp                                   not null default now(),
+    expires_at   timestamp                                   not null,
+    PRIMARY KEY (user_id, key)
+);
+
+CREATE INDEX idempotency_keys_expires_idx ON idempotency_keys (expires_at);
\ No newline at end of file
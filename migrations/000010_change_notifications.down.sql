@@ -0,0 +1,3 @@
+DROP TRIGGER audit_events_notify ON audit_events;
+
+DROP FUNCTION notify_audit_event();
\ No newline at end of file
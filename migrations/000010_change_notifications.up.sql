@@ -0,0 +1,13 @@
+CREATE FUNCTION notify_audit_event() RETURNS trigger AS
+$$
+BEGIN
+    PERFORM pg_notify('audit_events', NEW.id::text);
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_notify
+    AFTER INSERT
+    ON audit_events
+    FOR EACH ROW
+EXECUTE FUNCTION notify_audit_event();
\ No newline at end of file
@@ -0,0 +1,7 @@
+DROP TRIGGER audit_events_webhooks ON audit_events;
+
+DROP FUNCTION enqueue_webhook_deliveries();
+
+DROP TABLE webhook_deliveries;
+
+DROP TABLE webhooks;
\ No newline at end of file
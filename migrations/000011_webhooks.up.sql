@@ -0,0 +1,65 @@
+CREATE TABLE webhooks
+(
+    id            serial                                          not null unique,
+    user_id       int references users (id) on delete cascade     not null,
+    url           varchar(2048)                                   not null,
+    secret        varchar(64)                                     not null,
+    event_types   text[]                                          not null default '{}',
+    list_id       int references todo_lists (id) on delete cascade,
+    failure_count int                                             not null default 0,
+    disabled_at   timestamp,
+    created_at    timestamp                                       not null default now()
+);
+
+CREATE TABLE webhook_deliveries
+(
+    id              bigserial                                      not null unique,
+    webhook_id      int references webhooks (id) on delete cascade not null,
+    event_id        bigint,
+    event_type      varchar(64)                                    not null,
+    status          varchar(16)                                    not null default 'pending',
+    attempts        int                                            not null default 0,
+    response_code   int,
+    error           text,
+    next_attempt_at timestamp                                      not null default now(),
+    created_at      timestamp                                      not null default now(),
+    delivered_at    timestamp
+);
+
+CREATE INDEX webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+
+CREATE INDEX webhook_deliveries_webhook_idx ON webhook_deliveries (webhook_id, created_at);
+
+CREATE FUNCTION enqueue_webhook_deliveries() RETURNS trigger AS
+$$
+DECLARE
+    event_type text := NEW.entity_type || '.' || NEW.action;
+    list_ids   int[];
+BEGIN
+    list_ids := ARRAY [
+        CASE WHEN NEW.entity_type = 'list' THEN NEW.entity_id END,
+        (NEW.before ->> 'list_id')::int,
+        (NEW.after ->> 'list_id')::int,
+        (SELECT li.list_id FROM lists_items li WHERE NEW.entity_type = 'item' AND li.item_id = NEW.entity_id)
+        ];
+
+    INSERT INTO webhook_deliveries (webhook_id, event_id, event_type)
+    SELECT w.id, NEW.id, event_type
+    FROM webhooks w
+    WHERE w.disabled_at IS NULL
+      AND (cardinality(w.event_types) = 0 OR event_type = ANY (w.event_types))
+      AND EXISTS(SELECT 1
+                 FROM users_lists ul
+                 WHERE ul.user_id = w.user_id
+                   AND ul.list_id = ANY (list_ids)
+                   AND (w.list_id IS NULL OR ul.list_id = w.list_id));
+
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_webhooks
+    AFTER INSERT
+    ON audit_events
+    FOR EACH ROW
+EXECUTE FUNCTION enqueue_webhook_deliveries();
\ No newline at end of file
@@ -0,0 +1,31 @@
+DROP TRIGGER todo_items_tombstone ON todo_items;
+
+DROP TRIGGER todo_lists_tombstone ON todo_lists;
+
+DROP FUNCTION record_sync_tombstone();
+
+DROP TRIGGER lists_items_move ON lists_items;
+
+DROP FUNCTION track_item_move();
+
+DROP TRIGGER todo_lists_touch_items ON todo_lists;
+
+DROP FUNCTION touch_list_items();
+
+DROP TRIGGER todo_items_change_seq ON todo_items;
+
+DROP TRIGGER todo_lists_change_seq ON todo_lists;
+
+DROP FUNCTION bump_change_seq();
+
+DROP TABLE sync_tombstones;
+
+ALTER TABLE todo_items
+    DROP COLUMN change_xid,
+    DROP COLUMN change_seq;
+
+ALTER TABLE todo_lists
+    DROP COLUMN change_xid,
+    DROP COLUMN change_seq;
+
+DROP SEQUENCE sync_change_seq;
\ No newline at end of file
@@ -0,0 +1,114 @@
+CREATE SEQUENCE sync_change_seq;
+
+ALTER TABLE todo_lists
+    ADD COLUMN change_seq bigint not null default nextval('sync_change_seq'),
+    ADD COLUMN change_xid xid8   not null default pg_current_xact_id();
+
+ALTER TABLE todo_items
+    ADD COLUMN change_seq bigint not null default nextval('sync_change_seq'),
+    ADD COLUMN change_xid xid8   not null default pg_current_xact_id();
+
+CREATE TABLE sync_tombstones
+(
+    id          bigserial   not null unique,
+    entity_type varchar(32) not null,
+    entity_id   int         not null,
+    user_id     int references users (id) on delete cascade not null,
+    change_seq  bigint      not null default nextval('sync_change_seq'),
+    change_xid  xid8        not null default pg_current_xact_id(),
+    created_at  timestamp   not null default now()
+);
+
+CREATE INDEX todo_lists_change_idx ON todo_lists (change_xid, change_seq);
+
+CREATE INDEX todo_items_change_idx ON todo_items (change_xid, change_seq);
+
+CREATE INDEX sync_tombstones_user_idx ON sync_tombstones (user_id, change_xid, change_seq);
+
+CREATE FUNCTION bump_change_seq() RETURNS trigger AS
+$$
+BEGIN
+    NEW.change_seq := nextval('sync_change_seq');
+    NEW.change_xid := pg_current_xact_id();
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER todo_lists_change_seq
+    BEFORE UPDATE
+    ON todo_lists
+    FOR EACH ROW
+EXECUTE FUNCTION bump_change_seq();
+
+CREATE TRIGGER todo_items_change_seq
+    BEFORE UPDATE
+    ON todo_items
+    FOR EACH ROW
+EXECUTE FUNCTION bump_change_seq();
+
+CREATE FUNCTION touch_list_items() RETURNS trigger AS
+$$
+BEGIN
+    UPDATE todo_items SET change_seq = 0 WHERE id IN (SELECT item_id FROM lists_items WHERE list_id = NEW.id);
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER todo_lists_touch_items
+    AFTER UPDATE OF deleted_at
+    ON todo_lists
+    FOR EACH ROW
+    WHEN (OLD.deleted_at IS DISTINCT FROM NEW.deleted_at)
+EXECUTE FUNCTION touch_list_items();
+
+CREATE FUNCTION track_item_move() RETURNS trigger AS
+$$
+BEGIN
+    UPDATE todo_items SET change_seq = 0 WHERE id = NEW.item_id;
+
+    INSERT INTO sync_tombstones (entity_type, entity_id, user_id)
+    SELECT 'item', OLD.item_id, ul.user_id
+    FROM users_lists ul
+    WHERE ul.list_id = OLD.list_id
+      AND NOT EXISTS(SELECT 1 FROM users_lists nl WHERE nl.list_id = NEW.list_id AND nl.user_id = ul.user_id);
+
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER lists_items_move
+    AFTER UPDATE OF list_id
+    ON lists_items
+    FOR EACH ROW
+    WHEN (OLD.list_id IS DISTINCT FROM NEW.list_id)
+EXECUTE FUNCTION track_item_move();
+
+CREATE FUNCTION record_sync_tombstone() RETURNS trigger AS
+$$
+BEGIN
+    IF TG_TABLE_NAME = 'todo_lists' THEN
+        INSERT INTO sync_tombstones (entity_type, entity_id, user_id)
+        SELECT 'list', OLD.id, ul.user_id FROM users_lists ul WHERE ul.list_id = OLD.id;
+    ELSE
+        INSERT INTO sync_tombstones (entity_type, entity_id, user_id)
+        SELECT 'item', OLD.id, ul.user_id
+        FROM lists_items li
+                 JOIN users_lists ul ON li.list_id = ul.list_id
+        WHERE li.item_id = OLD.id;
+    END IF;
+
+    RETURN OLD;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER todo_lists_tombstone
+    BEFORE DELETE
+    ON todo_lists
+    FOR EACH ROW
+EXECUTE FUNCTION record_sync_tombstone();
+
+CREATE TRIGGER todo_items_tombstone
+    BEFORE DELETE
+    ON todo_items
+    FOR EACH ROW
+EXECUTE FUNCTION record_sync_tombstone();
\ No newline at end of file
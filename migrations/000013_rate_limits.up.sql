@@ -0,0 +1,9 @@
+CREATE UNLOGGED TABLE rate_limits
+(
+    key        varchar(255) primary key,
+    tokens     double precision not null,
+    allowed    boolean          not null,
+    updated_at timestamp        not null
+);
+
+CREATE INDEX rate_limits_updated_idx ON rate_limits (updated_at);
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP INDEX users_email_lower_idx;
+
+DROP INDEX users_lists_user_list_idx;
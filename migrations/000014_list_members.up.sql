@@ -0,0 +1,3 @@
+CREATE UNIQUE INDEX users_lists_user_list_idx ON users_lists (user_id, list_id);
+
+CREATE INDEX users_email_lower_idx ON users (lower(email));
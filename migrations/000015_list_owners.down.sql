@@ -0,0 +1,4 @@
+DROP INDEX users_lists_owner_idx;
+
+ALTER TABLE users_lists
+    DROP COLUMN role;
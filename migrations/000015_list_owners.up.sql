@@ -0,0 +1,10 @@
+ALTER TABLE users_lists
+    ADD COLUMN role varchar(16) not null default 'member';
+
+-- Lists were created with their creator as the only member, so the first
+-- membership of each list is its owner.
+UPDATE users_lists
+SET role = 'owner'
+WHERE id IN (SELECT min(id) FROM users_lists GROUP BY list_id);
+
+CREATE UNIQUE INDEX users_lists_owner_idx ON users_lists (list_id) WHERE role = 'owner';
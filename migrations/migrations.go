@@ -0,0 +1,8 @@
+// Package migrations holds the schema migrations, applied with
+// golang-migrate.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
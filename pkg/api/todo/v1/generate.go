@@ -0,0 +1,4 @@
+// Package todov1 holds the gRPC API generated from api/todo/v1/todo.proto.
+package todov1
+
+//go:generate protoc -I ../../../../api --go_out=../../../.. --go_opt=module=github.com/SavelyDev/crud-app --go-grpc_out=../../../.. --go-grpc_opt=module=github.com/SavelyDev/crud-app todo/v1/todo.proto
@@ -1,10 +1,15 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 
+	"github.com/XSAM/otelsql"
 	_ "github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Config struct {
@@ -16,11 +21,26 @@ type Config struct {
 	SSLMode  string
 }
 
-func New(cfg Config) (*sql.DB, error) {
-	connStr := fmt.Sprintf("host=%s port=%d user=%s dbname=%s password=%s sslmode=%s",
+// ConnString returns the lib/pq connection string for cfg.
+func ConnString(cfg Config) string {
+	return fmt.Sprintf("host=%s port=%d user=%s dbname=%s password=%s sslmode=%s",
 		cfg.Host, cfg.Port, cfg.Username, cfg.Name, cfg.Password, cfg.SSLMode)
+}
 
-	db, err := sql.Open("postgres", connStr)
+// New opens the database, tracing its statements as children of the
+// caller's span. Statements without a parent, e.g. of background jobs,
+// aren't traced.
+func New(cfg Config) (*sql.DB, error) {
+	db, err := otelsql.Open("postgres", ConnString(cfg),
+		otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitConnectorConnect: true,
+			OmitRows:             true,
+			SpanFilter: func(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+				return trace.SpanContextFromContext(ctx).IsValid()
+			},
+		}))
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// Checker reports whether the database is reachable and migrated.
+type Checker struct {
+	db      *sql.DB
+	version uint
+}
+
+// NewChecker checks for the schema to be migrated to at least version.
+func NewChecker(db *sql.DB, version uint) *Checker {
+	return &Checker{db: db, version: version}
+}
+
+func (c *Checker) Ping(ctx context.Context) error {
+	return c.db.PingContext(ctx)
+}
+
+// CheckMigrations reads the version golang-migrate left in
+// schema_migrations. A newer schema passes, so that instances of the
+// previous release stay ready while a rollout migrates the database.
+func (c *Checker) CheckMigrations(ctx context.Context) error {
+	var version uint
+	var dirty bool
+
+	err := c.db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("no migrations applied, expected version %d", c.version)
+	}
+	if err != nil {
+		return err
+	}
+
+	if dirty {
+		return fmt.Errorf("migration %d failed halfway, the schema is dirty", version)
+	}
+
+	if version < c.version {
+		return fmt.Errorf("schema is at version %d, expected %d", version, c.version)
+	}
+
+	return nil
+}
+
+// LatestMigration returns the newest version among the migrations in fsys,
+// which are named like 000001_init.up.sql.
+func LatestMigration(fsys fs.FS) (uint, error) {
+	files, err := fs.Glob(fsys, "*.up.sql")
+	if err != nil {
+		return 0, err
+	}
+
+	var latest uint
+	for _, file := range files {
+		prefix, _, _ := strings.Cut(file, "_")
+
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid migration name %q", file)
+		}
+
+		latest = max(latest, uint(version))
+	}
+
+	if latest == 0 {
+		return 0, errors.New("no migrations found")
+	}
+
+	return latest, nil
+}
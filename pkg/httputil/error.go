@@ -1,16 +1,37 @@
 package httputil
 
-import "github.com/gin-gonic/gin"
+import (
+	"context"
+	"errors"
+	"net/http"
 
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIdCtx is the gin context key of the request id, which is
+// returned with errors.
+const RequestIdCtx = "requestId"
+
+// NewError writes err as the response with status. A server error caused
+// by the request's deadline, such as its database timeout, is reported as
+// 504 Gateway Timeout instead.
 func NewError(c *gin.Context, status int, err error) {
+	_ = c.Error(err)
+
+	if status == http.StatusInternalServerError && errors.Is(err, context.DeadlineExceeded) {
+		status = http.StatusGatewayTimeout
+	}
+
 	er := HTTPError{
-		Code:    status,
-		Message: err.Error(),
+		Code:      status,
+		Message:   err.Error(),
+		RequestId: c.GetString(RequestIdCtx),
 	}
 	c.JSON(status, er)
 }
 
 type HTTPError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code      int    `json:"code"`
+	Message   string `json:"message"`
+	RequestId string `json:"request_id,omitempty"`
 }
@@ -0,0 +1,58 @@
+package httputil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNewError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		status     int
+		err        error
+		wantStatus int
+	}{
+		{name: "client error", status: http.StatusBadRequest, err: errors.New("invalid id param"), wantStatus: http.StatusBadRequest},
+		{name: "server error", status: http.StatusInternalServerError, err: errors.New("connection reset"), wantStatus: http.StatusInternalServerError},
+		{name: "deadline", status: http.StatusInternalServerError, err: context.DeadlineExceeded, wantStatus: http.StatusGatewayTimeout},
+		{
+			name:       "wrapped deadline",
+			status:     http.StatusInternalServerError,
+			err:        fmt.Errorf("get lists: %w", context.DeadlineExceeded),
+			wantStatus: http.StatusGatewayTimeout,
+		},
+		{name: "deadline with a chosen status", status: http.StatusConflict, err: context.DeadlineExceeded, wantStatus: http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rec)
+			c.Set(RequestIdCtx, "req-1")
+
+			NewError(c, tt.status, tt.err)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status %d, want %d", rec.Code, tt.wantStatus)
+			}
+
+			var body HTTPError
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+
+			if body.Code != tt.wantStatus || body.Message != tt.err.Error() || body.RequestId != "req-1" {
+				t.Errorf("got %+v, want code %d and message %q", body, tt.wantStatus, tt.err)
+			}
+		})
+	}
+}
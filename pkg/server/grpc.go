@@ -0,0 +1,48 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"google.golang.org/grpc"
+)
+
+type GRPCServer struct {
+	grpcServer *grpc.Server
+	addr       string
+}
+
+func NewGRPCServer(port int, grpcServer *grpc.Server) *GRPCServer {
+	return &GRPCServer{
+		grpcServer: grpcServer,
+		addr:       fmt.Sprintf(":%d", port),
+	}
+}
+
+func (s *GRPCServer) Run() error {
+	lis, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+
+	return s.grpcServer.Serve(lis)
+}
+
+// Shutdown waits for running calls to finish, or stops the server when the
+// context is done first.
+func (s *GRPCServer) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+		return ctx.Err()
+	}
+}
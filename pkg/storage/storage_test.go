@@ -0,0 +1,265 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is a local stand-in for S3, serving just the bucket and object
+// calls S3Store makes.
+type fakeS3 struct {
+	mu      sync.Mutex
+	buckets map[string]map[string]fakeObject
+}
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+}
+
+func newFakeS3(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(&fakeS3{buckets: make(map[string]map[string]fakeObject)})
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+
+	if key == "" {
+		switch r.Method {
+		case http.MethodHead:
+			if _, ok := f.buckets[bucket]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case http.MethodPut:
+			f.buckets[bucket] = make(map[string]fakeObject)
+		default:
+			w.WriteHeader(http.StatusNotImplemented)
+		}
+		return
+	}
+
+	objects, ok := f.buckets[bucket]
+	if !ok {
+		s3Error(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		data, err := readPayload(r)
+		if err != nil {
+			s3Error(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+
+		objects[key] = fakeObject{data: data, contentType: r.Header.Get("Content-Type")}
+		w.Header().Set("ETag", `"etag"`)
+
+	case http.MethodGet, http.MethodHead:
+		obj, ok := objects[key]
+		if !ok {
+			s3Error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+
+		w.Header().Set("Content-Type", obj.contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(obj.data)))
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.Header().Set("ETag", `"etag"`)
+		if r.Method == http.MethodGet {
+			w.Write(obj.data)
+		}
+
+	case http.MethodDelete:
+		delete(objects, key)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+// readPayload reads an upload body, decoding the aws-chunked encoding the
+// client streams with over plain HTTP.
+func readPayload(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var data []byte
+	body := bufio.NewReader(r.Body)
+	for {
+		header, err := body.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(header), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		chunk := make([]byte, size+2)
+		if _, err := io.ReadFull(body, chunk); err != nil {
+			return nil, err
+		}
+
+		if size == 0 {
+			return data, nil
+		}
+		data = append(data, chunk[:size]...)
+	}
+}
+
+func s3Error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+}
+
+func newStores(t *testing.T) map[string]BlobStore {
+	local, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s3, err := NewS3Store(context.Background(), S3Config{
+		Endpoint:  strings.TrimPrefix(newFakeS3(t).URL, "http://"),
+		Region:    "us-east-1",
+		Bucket:    "attachments",
+		AccessKey: "access",
+		SecretKey: "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return map[string]BlobStore{"local": local, "s3": s3}
+}
+
+func TestBlobStore(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		data []byte
+	}{
+		{name: "nested key", key: "items/1/photo.png", data: []byte("\x89PNG")},
+		{name: "flat key", key: "report.pdf", data: []byte("%PDF-1.7")},
+		{name: "empty blob", key: "items/2/empty.txt", data: []byte{}},
+		{name: "large blob", key: "items/3/large.bin", data: bytes.Repeat([]byte("blob"), 64<<10)},
+	}
+
+	for storeName, store := range newStores(t) {
+		for _, tt := range tests {
+			t.Run(storeName+"/"+tt.name, func(t *testing.T) {
+				ctx := context.Background()
+
+				if err := store.Put(ctx, tt.key, bytes.NewReader(tt.data), int64(len(tt.data)),
+					"application/octet-stream"); err != nil {
+					t.Fatalf("Put: %v", err)
+				}
+
+				blob, err := store.Get(ctx, tt.key)
+				if err != nil {
+					t.Fatalf("Get: %v", err)
+				}
+
+				data, err := io.ReadAll(blob)
+				blob.Close()
+				if err != nil {
+					t.Fatalf("reading blob: %v", err)
+				}
+
+				if !bytes.Equal(data, tt.data) {
+					t.Errorf("Get returned %d bytes, want the %d put", len(data), len(tt.data))
+				}
+
+				if err := store.Delete(ctx, tt.key); err != nil {
+					t.Fatalf("Delete: %v", err)
+				}
+
+				if _, err := store.Get(ctx, tt.key); !errors.Is(err, ErrNotFound) {
+					t.Errorf("Get after Delete: got %v, want ErrNotFound", err)
+				}
+
+				if err := store.Delete(ctx, tt.key); err != nil {
+					t.Errorf("Delete of a missing blob: %v", err)
+				}
+			})
+		}
+	}
+}
+
+func TestBlobStoreGetMissing(t *testing.T) {
+	for storeName, store := range newStores(t) {
+		t.Run(storeName, func(t *testing.T) {
+			if _, err := store.Get(context.Background(), "items/9/missing.png"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("got %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestNewS3StoreCreatesBucket(t *testing.T) {
+	srv := newFakeS3(t)
+	cfg := S3Config{Endpoint: strings.TrimPrefix(srv.URL, "http://"), Region: "us-east-1", Bucket: "fresh"}
+
+	for i := 0; i < 2; i++ {
+		if _, err := NewS3Store(context.Background(), cfg); err != nil {
+			t.Fatalf("NewS3Store, attempt %d: %v", i+1, err)
+		}
+	}
+}
+
+func TestLocalStoreRejectsKeysOutsideDir(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		key  string
+	}{
+		{name: "parent dir", key: "../escape.txt"},
+		{name: "nested parent dir", key: "items/../../escape.txt"},
+		{name: "dir itself", key: "."},
+		{name: "empty key", key: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if err := store.Put(ctx, tt.key, strings.NewReader("x"), 1, "text/plain"); err == nil {
+				t.Error("Put succeeded")
+			}
+
+			if _, err := store.Get(ctx, tt.key); err == nil || errors.Is(err, ErrNotFound) {
+				t.Errorf("Get: got %v, want an invalid key error", err)
+			}
+
+			if err := store.Delete(ctx, tt.key); err == nil {
+				t.Error("Delete succeeded")
+			}
+		})
+	}
+}